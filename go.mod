@@ -3,6 +3,7 @@ module github.com/dotnetmentor/racoon
 go 1.18
 
 require (
+	filippo.io/age v1.0.0
 	github.com/AlecAivazis/survey/v2 v2.3.7
 	github.com/andreyvit/diff v0.0.0-20170406064948-c7f18ee00883
 	github.com/arsham/figurine v1.3.0
@@ -10,6 +11,7 @@ require (
 	github.com/aws/aws-sdk-go-v2/config v1.26.5
 	github.com/aws/aws-sdk-go-v2/service/kms v1.27.9
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.48.0
+	github.com/aws/aws-sdk-go-v2/service/secretsmanager v1.26.0
 	github.com/aws/aws-sdk-go-v2/service/ssm v1.44.7
 	github.com/fatih/camelcase v1.0.0
 	github.com/go-chi/chi v1.5.5
@@ -19,6 +21,7 @@ require (
 	github.com/sirupsen/logrus v1.8.1
 	github.com/ttacon/chalk v0.0.0-20160626202418-22c06c80ed31
 	github.com/urfave/cli/v2 v2.3.0
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
 	golang.org/x/term v0.8.0
 	gopkg.in/yaml.v2 v2.4.0
 )
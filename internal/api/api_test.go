@@ -57,7 +57,7 @@ var _ = Describe("Properties", func() {
 				}
 
 				properties = api.PropertyList{}
-				property, isNew = api.NewProperty(properties, "Property1", "description", "layer-1", true, expectedRuleConfig, expectedFormattingConfigArg)
+				property, isNew = api.NewProperty(properties, "Property1", "description", "layer-1", true, "", expectedRuleConfig, expectedFormattingConfigArg)
 				properties = api.PropertyList{property}
 			})
 
@@ -113,7 +113,7 @@ var _ = Describe("Properties", func() {
 				}
 
 				properties = api.PropertyList{}
-				p, _ := api.NewProperty(properties, "Property1", "description", "layer-1", true, expectedRuleConfig, []config.FormattingConfig{})
+				p, _ := api.NewProperty(properties, "Property1", "description", "layer-1", true, "", expectedRuleConfig, []config.FormattingConfig{})
 				properties = api.PropertyList{p}
 
 				property, isNew = api.NewProperty(
@@ -122,6 +122,7 @@ var _ = Describe("Properties", func() {
 					"new description",
 					"layer-2",
 					false,
+					"",
 					config.RuleConfig{
 						Validation: config.ValidationRuleConfig{
 							AllowEmpty: true,
@@ -185,6 +186,7 @@ var _ = Describe("Properties", func() {
 					"Description",
 					layer.Name,
 					false,
+					"",
 					config.RuleConfig{},
 					[]config.FormattingConfig{},
 				)
@@ -234,6 +236,7 @@ var _ = Describe("Properties", func() {
 					"Description",
 					layer.Name,
 					false,
+					"",
 					config.RuleConfig{
 						Validation: config.ValidationRuleConfig{
 							AllowEmpty: true,
@@ -270,5 +273,52 @@ var _ = Describe("Properties", func() {
 				Expect(err).To(Not(HaveOccurred()))
 			})
 		})
+
+		When("property is validated using type and rules", func() {
+			validate := func(propertyType config.PropertyType, rules config.ValidationRuleConfig, raw string) error {
+				property, _ := api.NewProperty(properties, "Property1", "Description", layer.Name, false, propertyType, config.RuleConfig{Validation: rules}, []config.FormattingConfig{})
+				return property.Validate(api.NewValue(api.NewValueSource(layer, source), "key", raw, nil, false))
+			}
+			intPtr := func(i int) *int { return &i }
+			floatPtr := func(f float64) *float64 { return &f }
+
+			It("validates values by type", func() {
+				Expect(validate(config.PropertyTypeInt, config.ValidationRuleConfig{}, "42")).To(Succeed())
+				Expect(validate(config.PropertyTypeInt, config.ValidationRuleConfig{}, "4.2")).ToNot(Succeed())
+				Expect(validate(config.PropertyTypeFloat, config.ValidationRuleConfig{}, "4.2")).To(Succeed())
+				Expect(validate(config.PropertyTypeBool, config.ValidationRuleConfig{}, "false")).To(Succeed())
+				Expect(validate(config.PropertyTypeBool, config.ValidationRuleConfig{}, "flase")).ToNot(Succeed())
+				Expect(validate(config.PropertyTypeUrl, config.ValidationRuleConfig{}, "https://example.com/path")).To(Succeed())
+				Expect(validate(config.PropertyTypeUrl, config.ValidationRuleConfig{}, "example.com")).ToNot(Succeed())
+				Expect(validate(config.PropertyTypeDuration, config.ValidationRuleConfig{}, "1h30m")).To(Succeed())
+				Expect(validate(config.PropertyTypeDuration, config.ValidationRuleConfig{}, "90")).ToNot(Succeed())
+				Expect(validate(config.PropertyTypeJson, config.ValidationRuleConfig{}, `{"a": [1, 2]}`)).To(Succeed())
+				Expect(validate(config.PropertyTypeJson, config.ValidationRuleConfig{}, `{"a": `)).ToNot(Succeed())
+			})
+
+			It("validates values by rules", func() {
+				Expect(validate("", config.ValidationRuleConfig{Regexp: "^[a-z]+$"}, "abc")).To(Succeed())
+				Expect(validate("", config.ValidationRuleConfig{Regexp: "^[a-z]+$"}, "ABC")).ToNot(Succeed())
+				Expect(validate("", config.ValidationRuleConfig{MinLength: intPtr(3), MaxLength: intPtr(4)}, "abc")).To(Succeed())
+				Expect(validate("", config.ValidationRuleConfig{MinLength: intPtr(3)}, "ab")).ToNot(Succeed())
+				Expect(validate("", config.ValidationRuleConfig{MaxLength: intPtr(4)}, "abcde")).ToNot(Succeed())
+				Expect(validate("", config.ValidationRuleConfig{Enum: []string{"debug", "info"}}, "info")).To(Succeed())
+				Expect(validate("", config.ValidationRuleConfig{Enum: []string{"debug", "info"}}, "warn")).ToNot(Succeed())
+				Expect(validate(config.PropertyTypeInt, config.ValidationRuleConfig{Min: floatPtr(1), Max: floatPtr(65535)}, "8080")).To(Succeed())
+				Expect(validate(config.PropertyTypeInt, config.ValidationRuleConfig{Min: floatPtr(1), Max: floatPtr(65535)}, "0")).ToNot(Succeed())
+				Expect(validate(config.PropertyTypeInt, config.ValidationRuleConfig{Min: floatPtr(1), Max: floatPtr(65535)}, "70000")).ToNot(Succeed())
+			})
+
+			It("never includes sensitive values in validation errors", func() {
+				property, _ := api.NewProperty(properties, "Property1", "Description", layer.Name, true, config.PropertyTypeInt, config.RuleConfig{}, []config.FormattingConfig{})
+				err := property.Validate(api.NewValue(api.NewValueSource(layer, source), "key", "s3cr3t", nil, true))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).ToNot(ContainSubstring("s3cr3t"))
+			})
+
+			It("does not validate allowed empty values", func() {
+				Expect(validate(config.PropertyTypeInt, config.ValidationRuleConfig{AllowEmpty: true, MinLength: intPtr(1)}, "")).To(Succeed())
+			})
+		})
 	})
 })
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const driftHashPrefix = "hmac-sha256:"
+
+type DriftStatus string
+
+const (
+	DriftStatusAdded   DriftStatus = "added"
+	DriftStatusRemoved DriftStatus = "removed"
+	DriftStatusChanged DriftStatus = "changed"
+)
+
+// PropertyDrift describes how a property differs between a snapshot and the currently resolved values.
+// Values are nil when not set, sensitive values are never included.
+type PropertyDrift struct {
+	Name          string      `json:"name" yaml:"name"`
+	Status        DriftStatus `json:"status" yaml:"status"`
+	Sensitive     bool        `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`
+	SnapshotValue *string     `json:"snapshotValue,omitempty" yaml:"snapshotValue,omitempty"`
+	CurrentValue  *string     `json:"currentValue,omitempty" yaml:"currentValue,omitempty"`
+	SnapshotHash  string      `json:"snapshotHash,omitempty" yaml:"snapshotHash,omitempty"`
+	CurrentHash   string      `json:"currentHash,omitempty" yaml:"currentHash,omitempty"`
+}
+
+// Drift compares the properties of a decrypted snapshot to the currently resolved properties, in the order
+// properties are resolved followed by properties only found in the snapshot. Hashes of values are included when
+// hashes is true, allowing sensitive values to be compared without revealing them. Hashes are keyed using the data
+// key of the snapshot, snapshots without a data key use a random key and hashes only compare within a single report.
+func Drift(snapshot *EncryptedConfig, current []Property, hashes bool) ([]PropertyDrift, error) {
+	if snapshot.Encryption != nil {
+		return nil, fmt.Errorf("snapshot must be decrypted before comparing")
+	}
+
+	var key []byte
+	if hashes {
+		key = snapshot.hashKey
+		if len(key) == 0 {
+			key = make([]byte, sha256.Size)
+			if _, err := rand.Read(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	previous := make(map[string]EncryptedProperty)
+	for _, p := range snapshot.Properties {
+		previous[p.Name] = p
+	}
+
+	drift := make([]PropertyDrift, 0)
+	seen := make(map[string]bool)
+	for _, p := range current {
+		if seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+
+		value, sensitive := trackedValue(p)
+		prev, ok := previous[p.Name]
+		switch {
+		case !ok:
+			drift = append(drift, newPropertyDrift(p.Name, DriftStatusAdded, sensitive, nil, value, key))
+		case !equalValues(prev.Value, value):
+			drift = append(drift, newPropertyDrift(p.Name, DriftStatusChanged, sensitive || prev.Sensitive, prev.Value, value, key))
+		}
+	}
+
+	for _, p := range snapshot.Properties {
+		if seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+		drift = append(drift, newPropertyDrift(p.Name, DriftStatusRemoved, p.Sensitive, p.Value, nil, key))
+	}
+
+	return drift, nil
+}
+
+// newPropertyDrift creates the drift of a property, hashes are included when a key is provided
+func newPropertyDrift(name string, status DriftStatus, sensitive bool, snapshotValue, currentValue *string, key []byte) PropertyDrift {
+	d := PropertyDrift{
+		Name:      name,
+		Status:    status,
+		Sensitive: sensitive,
+	}
+	if !sensitive {
+		d.SnapshotValue = snapshotValue
+		d.CurrentValue = currentValue
+	}
+	if key != nil {
+		d.SnapshotHash = valueHash(key, snapshotValue)
+		d.CurrentHash = valueHash(key, currentValue)
+	}
+	return d
+}
+
+func equalValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// valueHash returns a short keyed hash of a value, empty when the value is not set. Hashes are keyed to prevent
+// low entropy values from being recovered from reports.
+func valueHash(key []byte, v *string) string {
+	if v == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(*v))
+	return driftHashPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// driftHashKey derives the key used to hash values from the data key of a snapshot
+func driftHashKey(dataKey []byte) []byte {
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte("racoon drift hashes"))
+	return mac.Sum(nil)
+}
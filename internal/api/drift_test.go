@@ -0,0 +1,135 @@
+package api_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/backend"
+	"github.com/dotnetmentor/racoon/internal/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var layer api.Layer
+
+	BeforeEach(func() {
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	property := func(name, value string, sensitive bool) api.Property {
+		p, _ := api.NewProperty(api.PropertyList{}, name, "", "base", sensitive, "", config.RuleConfig{}, nil)
+		p.SetValue(api.NewValue(api.NewValueSource(layer, api.SourceTypeDefault), "", value, nil, sensitive))
+		return p
+	}
+
+	snapshot := func(properties ...api.EncryptedProperty) *api.EncryptedConfig {
+		return &api.EncryptedConfig{Name: "myapp", Properties: properties}
+	}
+
+	value := func(v string) *string {
+		return &v
+	}
+
+	It("reports no drift for equal values", func() {
+		drift, err := api.Drift(snapshot(
+			api.EncryptedProperty{Name: "Password", Sensitive: true, Value: value("s3cr3t")},
+			api.EncryptedProperty{Name: "Port", Value: value("80")},
+		), []api.Property{property("Password", "s3cr3t", true), property("Port", "80", false)}, false)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(drift).To(BeEmpty())
+	})
+
+	It("reports added, removed and changed properties", func() {
+		drift, err := api.Drift(snapshot(
+			api.EncryptedProperty{Name: "Port", Value: value("80")},
+			api.EncryptedProperty{Name: "Removed", Value: value("gone")},
+		), []api.Property{property("Port", "81", false), property("Added", "new", false)}, false)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(drift).To(Equal([]api.PropertyDrift{
+			{Name: "Port", Status: api.DriftStatusChanged, SnapshotValue: value("80"), CurrentValue: value("81")},
+			{Name: "Added", Status: api.DriftStatusAdded, CurrentValue: value("new")},
+			{Name: "Removed", Status: api.DriftStatusRemoved, SnapshotValue: value("gone")},
+		}))
+	})
+
+	It("masks sensitive values, optionally including hashes", func() {
+		current := []api.Property{property("Password", "changed", true)}
+		previous := snapshot(api.EncryptedProperty{Name: "Password", Sensitive: true, Value: value("s3cr3t")})
+
+		drift, err := api.Drift(previous, current, false)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(drift).To(Equal([]api.PropertyDrift{{Name: "Password", Status: api.DriftStatusChanged, Sensitive: true}}))
+
+		drift, err = api.Drift(previous, current, true)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(drift[0].SnapshotValue).To(BeNil())
+		Expect(drift[0].CurrentValue).To(BeNil())
+		Expect(drift[0].SnapshotHash).To(HavePrefix("hmac-sha256:"))
+		Expect(drift[0].CurrentHash).To(HavePrefix("hmac-sha256:"))
+		Expect(drift[0].SnapshotHash).ToNot(Equal(drift[0].CurrentHash))
+	})
+
+	It("tracks properties selected by the filters used when exporting", func() {
+		var unfiltered *api.SnapshotMetadata
+		Expect(unfiltered.Tracked("Port")).To(BeTrue())
+
+		excluded := &api.SnapshotMetadata{Excludes: []string{"Port"}}
+		Expect(excluded.Tracked("Port")).To(BeFalse())
+		Expect(excluded.Tracked("Password")).To(BeTrue())
+
+		included := &api.SnapshotMetadata{Includes: []string{"Port"}, Excludes: []string{"Password"}}
+		Expect(included.Tracked("Port")).To(BeTrue())
+		Expect(included.Tracked("Password")).To(BeFalse())
+		Expect(included.Tracked("Other")).To(BeFalse())
+	})
+
+	It("keys hashes using the data key of the snapshot", func() {
+		os.Setenv("RACOON_BACKEND_PASSPHRASE", "passphrase")
+		defer os.Unsetenv("RACOON_BACKEND_PASSPHRASE")
+		encryption, err := backend.NewPassphraseEncryption(backend.PassphraseBackendConfig{})
+		Expect(err).To(Not(HaveOccurred()))
+
+		exported := func() *api.EncryptedConfig {
+			ec := api.NewEncryptedConfig(config.AppContext{}, testBackend{encryption: encryption})
+			Expect(ec.Track(property("Password", "1234", true))).To(Succeed())
+			b, err := json.Marshal(ec)
+			Expect(err).To(Not(HaveOccurred()))
+			return decrypted(b, encryption)
+		}
+
+		current := []api.Property{property("Password", "4321", true)}
+		previous := exported()
+		drift, err := api.Drift(previous, current, true)
+		Expect(err).To(Not(HaveOccurred()))
+		unkeyed := sha256.Sum256([]byte("1234"))
+		Expect(drift[0].SnapshotHash).ToNot(ContainSubstring(hex.EncodeToString(unkeyed[:])[:16]))
+
+		again, err := api.Drift(previous, current, true)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(again).To(Equal(drift))
+
+		other, err := api.Drift(exported(), current, true)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(other[0].SnapshotHash).ToNot(Equal(drift[0].SnapshotHash))
+	})
+
+	It("returns error for encrypted snapshots", func() {
+		encrypted := snapshot()
+		encrypted.Encryption = &api.ConfigEncryption{}
+		_, err := api.Drift(encrypted, []api.Property{}, false)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// decrypted unmarshals and decrypts a config as loaded from the backend
+func decrypted(b []byte, encryption backend.Encryption) *api.EncryptedConfig {
+	loaded := &api.EncryptedConfig{}
+	Expect(json.Unmarshal(b, loaded)).To(Succeed())
+	Expect(loaded.Decrypt(encryption)).To(Succeed())
+	return loaded
+}
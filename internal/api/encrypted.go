@@ -1,22 +1,79 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/user"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/dotnetmentor/racoon/internal/backend"
 	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/utils"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	snapshotFilename       = "racoon.config"
+	snapshotHistoryDir     = "history"
+	snapshotVersionTimeFmt = "20060102T150405Z"
 )
 
 type EncryptedConfig struct {
 	backend    backend.Backend
 	parameters config.OrderedParameterList
+	envelope   *backend.Envelope
+	metadata   config.AppMetadata
+	manifest   config.Manifest
+	excludes   []string
+	includes   []string
+	hashKey    []byte
 
 	Name       string              `json:"name" yaml:"name"`
 	Labels     map[string]string   `json:"labels" yaml:"labels"`
+	Snapshot   *SnapshotMetadata   `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`
+	Encryption *ConfigEncryption   `json:"encryption,omitempty" yaml:"encryption,omitempty"`
 	Properties []EncryptedProperty `json:"properties" yaml:"properties"`
 }
 
+// ConfigEncryption describes how sensitive values are encrypted, values are encrypted using a data key
+// wrapped by the backend encryption. Configs without it have each value encrypted by the backend encryption.
+type ConfigEncryption struct {
+	Version int    `json:"version" yaml:"version"`
+	Cipher  string `json:"cipher" yaml:"cipher"`
+	DataKey string `json:"dataKey" yaml:"dataKey"`
+}
+
+// SnapshotMetadata describes when, where and from what a config was exported
+type SnapshotMetadata struct {
+	Version       string            `json:"version" yaml:"version"`
+	CreatedAt     time.Time         `json:"createdAt" yaml:"createdAt"`
+	RacoonVersion string            `json:"racoonVersion,omitempty" yaml:"racoonVersion,omitempty"`
+	RacoonCommit  string            `json:"racoonCommit,omitempty" yaml:"racoonCommit,omitempty"`
+	User          string            `json:"user,omitempty" yaml:"user,omitempty"`
+	Hostname      string            `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Parameters    map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	ManifestHash  string            `json:"manifestHash,omitempty" yaml:"manifestHash,omitempty"`
+	Excludes      []string          `json:"excludes,omitempty" yaml:"excludes,omitempty"`
+	Includes      []string          `json:"includes,omitempty" yaml:"includes,omitempty"`
+}
+
+// Tracked returns true when a property was selected by the filters used when the snapshot was exported
+func (s *SnapshotMetadata) Tracked(name string) bool {
+	if s == nil {
+		return true
+	}
+	if utils.StringSliceContains(s.Excludes, name) {
+		return false
+	}
+	return len(s.Includes) == 0 || utils.StringSliceContains(s.Includes, name)
+}
+
 type EncryptedProperty struct {
 	Name        string  `json:"name" yaml:"name"`
 	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
@@ -28,6 +85,8 @@ func NewEncryptedConfig(ctx config.AppContext, backend backend.Backend) *Encrypt
 	ec := EncryptedConfig{
 		backend:    backend,
 		parameters: ctx.Parameters,
+		metadata:   ctx.Metadata,
+		manifest:   ctx.Manifest,
 
 		Name:       ctx.Manifest.Name,
 		Labels:     make(map[string]string),
@@ -49,38 +108,236 @@ func (ec *EncryptedConfig) Track(p Property) error {
 		Description: p.Description,
 	}
 
-	val := p.Value()
+	ep.Value, ep.Sensitive = trackedValue(p)
+	if ep.Sensitive && ep.Value != nil && len(*ep.Value) > 0 {
+		envelope, err := ec.dataKeyEnvelope()
+		if err != nil {
+			return err
+		}
+		ev, err := envelope.Seal(p.Name, []byte(*ep.Value))
+		if err != nil {
+			return err
+		}
+		ep.Value = &ev
+	}
 
+	ec.Properties = append(ec.Properties, ep)
+	return nil
+}
+
+// trackedValue returns the raw value of a property as tracked in the backend, nil when the value is not set or not found
+func trackedValue(p Property) (*string, bool) {
+	val := p.Value()
 	if val == nil {
-		ep.Value = nil
-	} else {
-		ep.Sensitive = val.Sensitive()
-
-		// If validation passes but we have a not found error for the resolved value, skip export
-		if !IsNotFoundError(val.Error()) {
-			if val.Sensitive() && len(val.Raw()) > 0 {
-				v, err := ec.backend.Encryption().Encrypt([]byte(val.Raw()))
-				if err != nil {
-					return err
-				}
-				ev := string(v)
-				ep.Value = &ev
-			} else {
-				dv := val.Raw()
-				ep.Value = &dv
-			}
+		return nil, false
+	}
+
+	// If validation passes but we have a not found error for the resolved value, skip export
+	if IsNotFoundError(val.Error()) {
+		return nil, val.Sensitive()
+	}
+
+	raw := val.Raw()
+	return &raw, val.Sensitive()
+}
+
+// Filter records the filters used to select tracked properties, filters are saved with the snapshot
+func (ec *EncryptedConfig) Filter(excludes, includes []string) {
+	ec.excludes = excludes
+	ec.includes = includes
+}
+
+// dataKeyEnvelope returns the envelope used to encrypt values, generating a data key on first use
+func (ec *EncryptedConfig) dataKeyEnvelope() (*backend.Envelope, error) {
+	if ec.envelope != nil {
+		return ec.envelope, nil
+	}
+
+	plain, wrapped, err := backend.NewDataKey(ec.backend.Encryption())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key, %v", err)
+	}
+
+	envelope, err := backend.NewEnvelope(plain)
+	if err != nil {
+		return nil, err
+	}
+
+	ec.envelope = envelope
+	ec.Encryption = &ConfigEncryption{
+		Version: backend.EnvelopeVersion,
+		Cipher:  backend.EnvelopeCipher,
+		DataKey: string(wrapped),
+	}
+	return envelope, nil
+}
+
+// Decrypt decrypts all sensitive values in place, unwrapping the data key once. Configs written before data keys
+// were introduced are decrypted one value at a time.
+func (ec *EncryptedConfig) Decrypt(encryption backend.Encryption) error {
+	var envelope *backend.Envelope
+	if ec.Encryption != nil {
+		if ec.Encryption.Version != backend.EnvelopeVersion || ec.Encryption.Cipher != backend.EnvelopeCipher {
+			return fmt.Errorf("unsupported config encryption (version=%d cipher=%s)", ec.Encryption.Version, ec.Encryption.Cipher)
+		}
+
+		key, err := encryption.Decrypt([]byte(ec.Encryption.DataKey))
+		if err != nil {
+			return fmt.Errorf("error decrypting data key: %v", err)
 		}
+		envelope, err = backend.NewEnvelope(key)
+		if err != nil {
+			return err
+		}
+		ec.hashKey = driftHashKey(key)
 	}
 
-	ec.Properties = append(ec.Properties, ep)
+	for i, p := range ec.Properties {
+		if !p.Sensitive || p.Value == nil || len(*p.Value) == 0 {
+			continue
+		}
+
+		var dv []byte
+		var err error
+		if envelope != nil {
+			dv, err = envelope.Open(p.Name, *p.Value)
+		} else {
+			dv, err = encryption.Decrypt([]byte(*p.Value))
+		}
+		if err != nil {
+			return fmt.Errorf("error decrypting property %s: %v", p.Name, err)
+		}
+
+		dsv := string(dv)
+		ec.Properties[i].Value = &dsv
+	}
+
+	ec.Encryption = nil
 	return nil
 }
 
+// Path returns the path of the latest snapshot
 func (ec *EncryptedConfig) Path() string {
+	return fmt.Sprintf("%s/%s", ec.basePath(), snapshotFilename)
+}
+
+// VersionPath returns the path of an immutable snapshot version
+func (ec *EncryptedConfig) VersionPath(version string) string {
+	return fmt.Sprintf("%s/%s/%s.config", ec.basePath(), snapshotHistoryDir, version)
+}
+
+func (ec *EncryptedConfig) basePath() string {
 	path := make([]string, 0)
 	for _, p := range ec.parameters {
 		path = append(path, fmt.Sprintf("%s/%s", p.Key, p.Value))
 	}
-	path = append(path, fmt.Sprintf("%s/%s", ec.Name, "racoon.config"))
+	path = append(path, ec.Name)
 	return strings.Join(path, "/")
 }
+
+// Save uploads the config as a new immutable version and as the latest snapshot, returning the version.
+// The latest snapshot is a full copy, readable without knowing the version, written once the version is saved.
+// Stores have no atomic writes across keys, when updating the latest snapshot fails the returned error includes the
+// saved version, loading it by version or exporting again recovers.
+func (ec *EncryptedConfig) Save(store backend.Store, now time.Time) (string, error) {
+	ec.Snapshot = &SnapshotMetadata{
+		CreatedAt:     now.UTC().Truncate(time.Second),
+		RacoonVersion: ec.metadata.Version,
+		RacoonCommit:  ec.metadata.Commit,
+		User:          currentUser(),
+		Parameters:    make(map[string]string),
+		ManifestHash:  manifestHash(ec.manifest),
+		Excludes:      ec.excludes,
+		Includes:      ec.includes,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		ec.Snapshot.Hostname = hostname
+	}
+	for _, p := range ec.parameters {
+		ec.Snapshot.Parameters[p.Key] = p.Value
+	}
+
+	// the version is the creation time followed by a hash of the content, sortable and unique
+	content, err := json.Marshal(ec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	ec.Snapshot.Version = fmt.Sprintf("%s-%s", ec.Snapshot.CreatedAt.Format(snapshotVersionTimeFmt), hex.EncodeToString(sum[:])[:12])
+
+	b, err := json.Marshal(ec)
+	if err != nil {
+		return "", err
+	}
+
+	if err := store.Upload(ec.VersionPath(ec.Snapshot.Version), b); err != nil {
+		return "", err
+	}
+	if err := store.Upload(ec.Path(), b); err != nil {
+		return ec.Snapshot.Version, fmt.Errorf("config saved as version %s but updating the latest snapshot %s failed, latest is out of date until exported again: %v", ec.Snapshot.Version, ec.Path(), err)
+	}
+	return ec.Snapshot.Version, nil
+}
+
+// Versions returns the versions of the config stored in the backend, latest version first
+func (ec *EncryptedConfig) Versions(store backend.Store) ([]string, error) {
+	prefix := fmt.Sprintf("%s/%s/", ec.basePath(), snapshotHistoryDir)
+	keys, err := store.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0)
+	for _, k := range keys {
+		if strings.HasPrefix(k, prefix) && strings.HasSuffix(k, ".config") {
+			v := strings.TrimSuffix(strings.TrimPrefix(k, prefix), ".config")
+			if !strings.Contains(v, "/") {
+				versions = append(versions, v)
+			}
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	return versions, nil
+}
+
+// Load downloads a version of the config, or the latest snapshot when version is empty
+func (ec *EncryptedConfig) Load(store backend.Store, version string) (*EncryptedConfig, error) {
+	path := ec.Path()
+	if len(version) > 0 {
+		path = ec.VersionPath(version)
+	}
+
+	b, err := store.Download(path)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading config %s: %v", path, err)
+	}
+
+	loaded := &EncryptedConfig{}
+	if err := json.Unmarshal(b, loaded); err != nil {
+		return nil, fmt.Errorf("error unmarshalling config %s: %v", path, err)
+	}
+	return loaded, nil
+}
+
+// IsSnapshotPath returns true for paths of latest snapshots, excluding versions kept as history
+func IsSnapshotPath(path string) bool {
+	return path == snapshotFilename || strings.HasSuffix(path, "/"+snapshotFilename)
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && len(u.Username) > 0 {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// manifestHash returns a hash of the manifest, including any extended manifests
+func manifestHash(m config.Manifest) string {
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
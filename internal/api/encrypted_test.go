@@ -0,0 +1,236 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/backend"
+	"github.com/dotnetmentor/racoon/internal/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// countingEncryption counts calls to the wrapped encryption
+type countingEncryption struct {
+	backend.Encryption
+	encrypted int
+	decrypted int
+}
+
+func (e *countingEncryption) Encrypt(v []byte) ([]byte, error) {
+	e.encrypted++
+	return e.Encryption.Encrypt(v)
+}
+
+func (e *countingEncryption) Decrypt(v []byte) ([]byte, error) {
+	e.decrypted++
+	return e.Encryption.Decrypt(v)
+}
+
+type testBackend struct {
+	store      backend.Store
+	encryption backend.Encryption
+}
+
+// recordingStore records listed prefixes and optionally fails uploading latest snapshots
+type recordingStore struct {
+	backend.Store
+	prefixes     []string
+	failSnapshot bool
+}
+
+func (s *recordingStore) List(prefix string) ([]string, error) {
+	s.prefixes = append(s.prefixes, prefix)
+	return s.Store.List(prefix)
+}
+
+func (s *recordingStore) Upload(key string, body []byte) error {
+	if s.failSnapshot && api.IsSnapshotPath(key) {
+		return fmt.Errorf("upload failed")
+	}
+	return s.Store.Upload(key, body)
+}
+
+func (b testBackend) Store() backend.Store           { return b.store }
+func (b testBackend) Encryption() backend.Encryption { return b.encryption }
+
+var _ = Describe("EncryptedConfig", func() {
+	var ctx config.AppContext
+	var encryption *countingEncryption
+	var layer api.Layer
+
+	BeforeEach(func() {
+		os.Setenv("RACOON_BACKEND_PASSPHRASE", "passphrase")
+		pe, err := backend.NewPassphraseEncryption(backend.PassphraseBackendConfig{})
+		Expect(err).To(Not(HaveOccurred()))
+		encryption = &countingEncryption{Encryption: pe}
+
+		ctx = config.AppContext{
+			Manifest:   config.Manifest{MetadataConfig: config.MetadataConfig{Name: "myapp"}},
+			Parameters: config.OrderedParameterList{{Key: "context", Value: "dev"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("RACOON_BACKEND_PASSPHRASE")
+	})
+
+	property := func(name, value string, sensitive bool) api.Property {
+		p, _ := api.NewProperty(api.PropertyList{}, name, "", "base", sensitive, "", config.RuleConfig{}, nil)
+		p.SetValue(api.NewValue(api.NewValueSource(layer, api.SourceTypeDefault), "", value, nil, sensitive))
+		return p
+	}
+
+	roundtrip := func(ec *api.EncryptedConfig) *api.EncryptedConfig {
+		b, err := json.Marshal(ec)
+		Expect(err).To(Not(HaveOccurred()))
+		stored := &api.EncryptedConfig{}
+		Expect(json.Unmarshal(b, stored)).To(Succeed())
+		return stored
+	}
+
+	It("encrypts sensitive values using a single data key", func() {
+		ec := api.NewEncryptedConfig(ctx, testBackend{encryption: encryption})
+		Expect(ec.Track(property("ApiKey", "key", true))).To(Succeed())
+		Expect(ec.Track(property("Password", "s3cr3t", true))).To(Succeed())
+		Expect(ec.Track(property("Port", "80", false))).To(Succeed())
+		Expect(encryption.encrypted).To(Equal(1))
+
+		stored := roundtrip(ec)
+		Expect(stored.Encryption).ToNot(BeNil())
+		Expect(stored.Encryption.Cipher).To(Equal(backend.EnvelopeCipher))
+		Expect(*stored.Properties[1].Value).ToNot(Equal("s3cr3t"))
+		Expect(*stored.Properties[2].Value).To(Equal("80"))
+
+		Expect(stored.Decrypt(encryption)).To(Succeed())
+		Expect(encryption.decrypted).To(Equal(1))
+		Expect(stored.Encryption).To(BeNil())
+		Expect(*stored.Properties[0].Value).To(Equal("key"))
+		Expect(*stored.Properties[1].Value).To(Equal("s3cr3t"))
+	})
+
+	It("does not generate a data key without sensitive values", func() {
+		ec := api.NewEncryptedConfig(ctx, testBackend{encryption: encryption})
+		Expect(ec.Track(property("Port", "80", false))).To(Succeed())
+		Expect(encryption.encrypted).To(Equal(0))
+		Expect(roundtrip(ec).Encryption).To(BeNil())
+	})
+
+	It("rejects values moved between properties", func() {
+		ec := api.NewEncryptedConfig(ctx, testBackend{encryption: encryption})
+		Expect(ec.Track(property("ApiKey", "key", true))).To(Succeed())
+		Expect(ec.Track(property("Password", "s3cr3t", true))).To(Succeed())
+
+		stored := roundtrip(ec)
+		stored.Properties[0].Value = stored.Properties[1].Value
+		Expect(stored.Decrypt(encryption)).To(MatchError(ContainSubstring("ApiKey")))
+	})
+
+	It("decrypts configs with values encrypted one by one", func() {
+		ev, err := encryption.Encrypt([]byte("s3cr3t"))
+		Expect(err).To(Not(HaveOccurred()))
+		value := string(ev)
+
+		stored := &api.EncryptedConfig{
+			Name: "myapp",
+			Properties: []api.EncryptedProperty{
+				{Name: "Password", Sensitive: true, Value: &value},
+			},
+		}
+		Expect(stored.Decrypt(encryption)).To(Succeed())
+		Expect(*stored.Properties[0].Value).To(Equal("s3cr3t"))
+	})
+
+	Describe("Save", func() {
+		var b testBackend
+
+		BeforeEach(func() {
+			b = testBackend{
+				store:      backend.LocalBackendStore{Config: backend.LocalBackendConfig{Path: GinkgoT().TempDir()}},
+				encryption: encryption,
+			}
+			ctx.Metadata = config.AppMetadata{Version: "1.2.3", Commit: "abc"}
+		})
+
+		save := func(now time.Time, value string) string {
+			ec := api.NewEncryptedConfig(ctx, b)
+			Expect(ec.Track(property("Password", value, true))).To(Succeed())
+			version, err := ec.Save(b.Store(), now)
+			Expect(err).To(Not(HaveOccurred()))
+			return version
+		}
+
+		It("keeps every version and the latest snapshot", func() {
+			first := save(time.Date(2026, 10, 13, 9, 0, 0, 0, time.UTC), "tuesday")
+			second := save(time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC), "thursday")
+			Expect(first).To(HavePrefix("20261013T090000Z-"))
+
+			keys, err := b.Store().List("")
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(keys).To(Equal([]string{
+				"context/dev/myapp/history/" + first + ".config",
+				"context/dev/myapp/history/" + second + ".config",
+				"context/dev/myapp/racoon.config",
+			}))
+			Expect(api.IsSnapshotPath(keys[0])).To(BeFalse())
+			Expect(api.IsSnapshotPath(keys[2])).To(BeTrue())
+
+			ec := api.NewEncryptedConfig(ctx, b)
+			versions, err := ec.Versions(b.Store())
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(versions).To(Equal([]string{second, first}))
+
+			latest, err := ec.Load(b.Store(), "")
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(latest.Snapshot.Version).To(Equal(second))
+
+			tuesday, err := ec.Load(b.Store(), first)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(tuesday.Snapshot.RacoonVersion).To(Equal("1.2.3"))
+			Expect(tuesday.Snapshot.Parameters).To(Equal(map[string]string{"context": "dev"}))
+			Expect(tuesday.Snapshot.ManifestHash).ToNot(BeEmpty())
+			Expect(tuesday.Decrypt(encryption)).To(Succeed())
+			Expect(*tuesday.Properties[0].Value).To(Equal("tuesday"))
+		})
+
+		It("returns error for unknown versions", func() {
+			_, err := api.NewEncryptedConfig(ctx, b).Load(b.Store(), "20200101T000000Z-000000000000")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("lists versions using the history prefix", func() {
+			store := &recordingStore{Store: b.store}
+			b.store = store
+			version := save(time.Date(2026, 10, 13, 9, 0, 0, 0, time.UTC), "tuesday")
+
+			versions, err := api.NewEncryptedConfig(ctx, b).Versions(store)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(versions).To(Equal([]string{version}))
+			Expect(store.prefixes).To(Equal([]string{"context/dev/myapp/history/"}))
+		})
+
+		It("returns the saved version when updating the latest snapshot fails", func() {
+			first := save(time.Date(2026, 10, 13, 9, 0, 0, 0, time.UTC), "tuesday")
+
+			store := &recordingStore{Store: b.store, failSnapshot: true}
+			ec := api.NewEncryptedConfig(ctx, b)
+			Expect(ec.Track(property("Password", "thursday", true))).To(Succeed())
+			second, err := ec.Save(store, time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC))
+			Expect(err).To(MatchError(ContainSubstring("config saved as version " + second)))
+
+			latest, err := ec.Load(b.Store(), "")
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(latest.Snapshot.Version).To(Equal(first))
+
+			saved, err := ec.Load(b.Store(), second)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(saved.Decrypt(encryption)).To(Succeed())
+			Expect(*saved.Properties[0].Value).To(Equal("thursday"))
+		})
+	})
+})
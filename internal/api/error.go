@@ -136,6 +136,11 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("ValidationError, %s (value=<nil>)", e.msg)
 }
 
+// Message returns the message of the error without the value
+func (e *ValidationError) Message() string {
+	return e.msg
+}
+
 type FormattingError struct {
 	msg    string
 	errors []*FormattingError
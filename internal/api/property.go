@@ -1,18 +1,26 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/utils"
 )
 
-func NewProperty(properties PropertyList, name, description, source string, sensitive bool, rules config.RuleConfig, formatting []config.FormattingConfig) (property Property, isNew bool) {
+func NewProperty(properties PropertyList, name, description, source string, sensitive bool, propertyType config.PropertyType, rules config.RuleConfig, formatting []config.FormattingConfig) (property Property, isNew bool) {
 	property = Property{
 		Name:        name,
 		Description: description,
 		source:      source,
 		sensitive:   sensitive,
+		typ:         propertyType,
 		rules:       rules,
 		formatting:  formatting,
 		values:      make(ValueList, 0),
@@ -39,6 +47,11 @@ func NewProperty(properties PropertyList, name, description, source string, sens
 			}
 			property.rules = ep.rules
 
+			if len(property.typ) > 0 && property.typ != ep.typ {
+				apiLog.Warnf("%s/%s, overriding type is not allowed, type already defined in %s", property.source, property.Name, ep.source)
+			}
+			property.typ = ep.typ
+
 			break
 		}
 	}
@@ -58,6 +71,7 @@ type Property struct {
 	source     string
 	values     ValueList
 	sensitive  bool
+	typ        config.PropertyType
 	rules      config.RuleConfig
 	formatting []config.FormattingConfig
 }
@@ -109,6 +123,14 @@ func (p Property) Sensitive() bool {
 	return p.sensitive
 }
 
+// Type returns the type of the property, properties without a type are strings
+func (p Property) Type() config.PropertyType {
+	if len(p.typ) == 0 {
+		return config.PropertyTypeString
+	}
+	return p.typ
+}
+
 func (p Property) Rules() config.RuleConfig {
 	return p.rules
 }
@@ -119,7 +141,7 @@ func (p Property) Formatting() []config.FormattingConfig {
 
 func (p Property) WritableFormatters() (writable []config.FormattingConfig) {
 	for _, fc := range p.Formatting() {
-		if SourceType(fc.Source.SourceType()).Writable() {
+		if SourceType(fc.Source.SourceType()).Writable() || fc.Source != nil && fc.Source.Plugin != nil {
 			writable = append(writable, fc)
 		}
 	}
@@ -143,5 +165,92 @@ func (p Property) Validate(v Value) error {
 		return NewValidationError(fmt.Sprintf("empty value not allowed for property %s", p.Name), v)
 	}
 
+	// An allowed empty value is not validated further
+	if len(v.Raw()) == 0 {
+		return nil
+	}
+
+	if err := p.validateType(v.Raw()); err != nil {
+		return NewValidationError(fmt.Sprintf("value of property %s is not a valid %s, %v", p.Name, p.Type(), err), v)
+	}
+
+	if err := p.validateRules(v.Raw()); err != nil {
+		return NewValidationError(fmt.Sprintf("value of property %s is invalid, %v", p.Name, err), v)
+	}
+
+	return nil
+}
+
+// NOTE: Errors must never include the value, it may be sensitive
+
+func (p Property) validateType(raw string) error {
+	switch p.Type() {
+	case config.PropertyTypeInt:
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+	case config.PropertyTypeFloat:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return fmt.Errorf("expected a number")
+		}
+	case config.PropertyTypeBool:
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+	case config.PropertyTypeUrl:
+		u, err := url.Parse(raw)
+		if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+			return fmt.Errorf("expected an absolute url")
+		}
+	case config.PropertyTypeDuration:
+		if _, err := time.ParseDuration(raw); err != nil {
+			return fmt.Errorf("expected a duration (example: 1h30m)")
+		}
+	case config.PropertyTypeJson:
+		if !json.Valid([]byte(raw)) {
+			return fmt.Errorf("expected json")
+		}
+	}
+	return nil
+}
+
+func (p Property) validateRules(raw string) error {
+	rules := p.Rules().Validation
+
+	if len(rules.Regexp) > 0 {
+		match, err := regexp.MatchString(rules.Regexp, raw)
+		if err != nil {
+			return fmt.Errorf("invalid regexp %s, %v", rules.Regexp, err)
+		}
+		if !match {
+			return fmt.Errorf("must match regexp %s", rules.Regexp)
+		}
+	}
+
+	length := utf8.RuneCountInString(raw)
+	if rules.MinLength != nil && length < *rules.MinLength {
+		return fmt.Errorf("must be at least %d characters long", *rules.MinLength)
+	}
+	if rules.MaxLength != nil && length > *rules.MaxLength {
+		return fmt.Errorf("must be at most %d characters long", *rules.MaxLength)
+	}
+
+	if len(rules.Enum) > 0 && !utils.StringSliceContains(rules.Enum, raw) {
+		return fmt.Errorf("must be one of %v", rules.Enum)
+	}
+
+	if rules.Min != nil || rules.Max != nil {
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		if rules.Min != nil && n < *rules.Min {
+			return fmt.Errorf("must be greater than or equal to %v", *rules.Min)
+		}
+		if rules.Max != nil && n > *rules.Max {
+			return fmt.Errorf("must be less than or equal to %v", *rules.Max)
+		}
+	}
+
 	return nil
 }
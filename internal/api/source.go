@@ -1,24 +1,51 @@
 package api
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	SourceTypeAwsParameterStore SourceType = "awsParameterStore"
+	SourceTypeAwsSecretsManager SourceType = "awsSecretsManager"
 	SourceTypeDefault           SourceType = "default"
+	SourceTypeEncryptedFile     SourceType = "encryptedFile"
 	SourceTypeEnvironment       SourceType = "env"
+	SourceTypeExec              SourceType = "exec"
+	SourceTypeFile              SourceType = "file"
 	SourceTypeFormatter         SourceType = "formatter"
 	SourceTypeLiteral           SourceType = "literal"
 	SourceTypeParameter         SourceType = "parameter"
+	SourceTypeSops              SourceType = "sops"
+	SourceTypeVault             SourceType = "vault"
 )
 
+// pluginSourceTypePrefix prefixes the name of a plugin in the source type of values read using the plugin
+const pluginSourceTypePrefix = "plugin:"
+
 type SourceType string
 
+// PluginSourceType returns the source type of values read using the named plugin
+func PluginSourceType(name string) SourceType {
+	return SourceType(pluginSourceTypePrefix + name)
+}
+
+// Plugin returns the name of the plugin for source types of plugins
+func (st SourceType) Plugin() (string, bool) {
+	if strings.HasPrefix(string(st), pluginSourceTypePrefix) {
+		return strings.TrimPrefix(string(st), pluginSourceTypePrefix), true
+	}
+	return "", false
+}
+
 func (st SourceType) Writable() bool {
 	switch st {
-	case SourceTypeAwsParameterStore:
+	case SourceTypeAwsParameterStore, SourceTypeAwsSecretsManager, SourceTypeVault, SourceTypeEncryptedFile:
 		return true
 	default:
-		return false
+		// plugins are expected to reject writes when not supported
+		_, plugin := st.Plugin()
+		return plugin
 	}
 }
 
@@ -47,10 +74,5 @@ func (s ValueSource) Type() SourceType {
 }
 
 func (s ValueSource) Writable() bool {
-	switch s.sourceType {
-	case SourceTypeAwsParameterStore:
-		return true
-	default:
-		return false
-	}
+	return s.sourceType.Writable()
 }
@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/dotnetmentor/racoon/internal/environment"
+)
+
+const (
+	ageDefaultIdentityEnv = "RACOON_AGE_IDENTITY"
+)
+
+// AgeEncryption encrypts values for age recipients, values are decrypted using an age identity.
+// Recipients default to the recipient of the identity, allowing a single identity to be used in development.
+type AgeEncryption struct {
+	Config     AgeBackendConfig
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+func NewAgeEncryption(config AgeBackendConfig) (*AgeEncryption, error) {
+	encryption := &AgeEncryption{
+		Config: config,
+	}
+
+	identities, err := ageIdentities(config)
+	if err != nil {
+		return nil, err
+	}
+	encryption.identities = identities
+
+	for _, r := range config.Recipients {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %s, %v", r, err)
+		}
+		encryption.recipients = append(encryption.recipients, recipient)
+	}
+
+	if len(config.RecipientsFile) > 0 {
+		f, err := os.Open(config.RecipientsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age recipients file, %v", err)
+		}
+		defer f.Close()
+
+		recipients, err := age.ParseRecipients(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age recipients file %s, %v", config.RecipientsFile, err)
+		}
+		encryption.recipients = append(encryption.recipients, recipients...)
+	}
+
+	if len(encryption.recipients) == 0 {
+		for _, i := range identities {
+			if xi, ok := i.(*age.X25519Identity); ok {
+				encryption.recipients = append(encryption.recipients, xi.Recipient())
+			}
+		}
+	}
+
+	if len(encryption.recipients) == 0 && len(encryption.identities) == 0 {
+		return nil, fmt.Errorf("age encryption requires recipients or an identity, set backend.encryption.age.recipients or environment variable %s", ageIdentityEnv(config))
+	}
+
+	return encryption, nil
+}
+
+func (encryption AgeEncryption) Encrypt(v []byte) ([]byte, error) {
+	if len(encryption.recipients) == 0 {
+		return nil, fmt.Errorf("age recipients not set")
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := age.Encrypt(buf, encryption.recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(v); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+func (encryption AgeEncryption) Decrypt(v []byte) ([]byte, error) {
+	if len(encryption.identities) == 0 {
+		return nil, fmt.Errorf("age identity not set, set environment variable %s or backend.encryption.age.identityFile", ageIdentityEnv(encryption.Config))
+	}
+
+	b, err := base64.StdEncoding.DecodeString(string(v))
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(b), encryption.identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// ageIdentities parses identities from the configured environment variable and identity file, identities are optional
+func ageIdentities(config AgeBackendConfig) ([]age.Identity, error) {
+	identities := make([]age.Identity, 0)
+
+	env := ageIdentityEnv(config)
+	if v := environment.StringVar(env, ""); len(v) > 0 {
+		ids, err := age.ParseIdentities(strings.NewReader(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity in environment variable %s, %v", env, err)
+		}
+		identities = append(identities, ids...)
+	}
+
+	if len(config.IdentityFile) > 0 {
+		f, err := os.Open(config.IdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age identity file, %v", err)
+		}
+		defer f.Close()
+
+		ids, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identity file %s, %v", config.IdentityFile, err)
+		}
+		identities = append(identities, ids...)
+	}
+
+	return identities, nil
+}
+
+func ageIdentityEnv(config AgeBackendConfig) string {
+	if len(config.IdentityEnv) > 0 {
+		return config.IdentityEnv
+	}
+	return ageDefaultIdentityEnv
+}
@@ -4,29 +4,31 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
 )
 
 type AwsKmsEncryption struct {
 	Context   context.Context
 	Config    AwsKmsBackendConfig
 	AwsConfig aws.Config
+
+	once   sync.Once
+	client *kms.Client
 }
 
-func (encryption AwsKmsEncryption) Encrypt(v []byte) ([]byte, error) {
+func (encryption *AwsKmsEncryption) Encrypt(v []byte) ([]byte, error) {
 	// Key
 	keyId := encryption.Config.KmsKey
 	if len(keyId) == 0 {
 		return nil, fmt.Errorf("kms key not set")
 	}
 
-	// Create KMS service client
-	kmsClient := kms.NewFromConfig(encryption.AwsConfig)
-
 	// Encrypt the data
-	er, err := kmsClient.Encrypt(encryption.Context, &kms.EncryptInput{
+	er, err := encryption.kmsClient().Encrypt(encryption.Context, &kms.EncryptInput{
 		KeyId:     aws.String(keyId),
 		Plaintext: v,
 	})
@@ -39,7 +41,7 @@ func (encryption AwsKmsEncryption) Encrypt(v []byte) ([]byte, error) {
 	return []byte(base64.StdEncoding.EncodeToString(er.CiphertextBlob)), nil
 }
 
-func (encryption AwsKmsEncryption) Decrypt(v []byte) ([]byte, error) {
+func (encryption *AwsKmsEncryption) Decrypt(v []byte) ([]byte, error) {
 	keyId := encryption.Config.KmsKey
 	if len(keyId) == 0 {
 		return nil, fmt.Errorf("kms key not set")
@@ -50,11 +52,8 @@ func (encryption AwsKmsEncryption) Decrypt(v []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	// Create KMS service client
-	svc := kms.NewFromConfig(encryption.AwsConfig)
-
 	// Decrypt the data
-	er, err := svc.Decrypt(encryption.Context, &kms.DecryptInput{
+	er, err := encryption.kmsClient().Decrypt(encryption.Context, &kms.DecryptInput{
 		KeyId:          aws.String(keyId),
 		CiphertextBlob: bytes,
 	})
@@ -66,3 +65,29 @@ func (encryption AwsKmsEncryption) Decrypt(v []byte) ([]byte, error) {
 
 	return er.Plaintext, nil
 }
+
+// GenerateDataKey generates an AES-256 data key, the wrapped key is encoded like values returned by Encrypt
+func (encryption *AwsKmsEncryption) GenerateDataKey() ([]byte, []byte, error) {
+	keyId := encryption.Config.KmsKey
+	if len(keyId) == 0 {
+		return nil, nil, fmt.Errorf("kms key not set")
+	}
+
+	dk, err := encryption.kmsClient().GenerateDataKey(encryption.Context, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(keyId),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dk.Plaintext, []byte(base64.StdEncoding.EncodeToString(dk.CiphertextBlob)), nil
+}
+
+// kmsClient returns the KMS service client, created once and reused for all calls
+func (encryption *AwsKmsEncryption) kmsClient() *kms.Client {
+	encryption.once.Do(func() {
+		encryption.client = kms.NewFromConfig(encryption.AwsConfig)
+	})
+	return encryption.client
+}
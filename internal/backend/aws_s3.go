@@ -47,20 +47,29 @@ func (b AwsS3BackendStore) Download(key string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func (b AwsS3BackendStore) List() ([]string, error) {
+func (b AwsS3BackendStore) List(prefix string) ([]string, error) {
 	bucket := b.Config.Bucket
 
-	s3Client := s3.NewFromConfig(b.AwsConfig)
-	output, err := s3Client.ListObjectsV2(b.Context, &s3.ListObjectsV2Input{
+	input := &s3.ListObjectsV2Input{
 		Bucket: aws.String(bucket),
-	})
-	if err != nil {
-		return nil, err
 	}
+	if len(prefix) > 0 {
+		input.Prefix = aws.String(prefix)
+	}
+
+	s3Client := s3.NewFromConfig(b.AwsConfig)
+	paginator := s3.NewListObjectsV2Paginator(s3Client, input)
 
+	// history makes buckets grow beyond a single page of keys
 	keys := make([]string, 0)
-	for _, o := range output.Contents {
-		keys = append(keys, *o.Key)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(b.Context)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range output.Contents {
+			keys = append(keys, *o.Key)
+		}
 	}
 	return keys, nil
 }
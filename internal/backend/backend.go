@@ -2,6 +2,9 @@ package backend
 
 import (
 	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 )
 
 type Backend interface {
@@ -9,6 +12,42 @@ type Backend interface {
 	Encryption() Encryption
 }
 
+// New creates a backend using the configured store and encryption, aws configuration is only loaded when used
 func New(ctx context.Context, config BackendConfig) (Backend, error) {
-	return NewAwsBackend(ctx, config)
+	var awsConfig aws.Config
+	if config.Store.AwsS3 != nil || config.Encryption.AwsKms != nil {
+		c, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		awsConfig = c
+	}
+
+	store, err := NewStore(ctx, config.Store, awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	encryption, err := NewEncryption(ctx, config.Encryption, awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backend{
+		store:      store,
+		encryption: encryption,
+	}, nil
+}
+
+type backend struct {
+	store      Store
+	encryption Encryption
+}
+
+func (b *backend) Store() Store {
+	return b.store
+}
+
+func (b *backend) Encryption() Encryption {
+	return b.encryption
 }
@@ -0,0 +1,18 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBackend(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Backend Suite")
+}
+
+// awsConfigNotUsed is passed where aws is not configured
+var awsConfigNotUsed = aws.Config{}
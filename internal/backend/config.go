@@ -8,10 +8,13 @@ type BackendConfig struct {
 
 type StoreConfig struct {
 	AwsS3 *AwsS3BackendConfig `json:"awsS3,omitempty" yaml:"awsS3,omitempty"`
+	Local *LocalBackendConfig `json:"local,omitempty" yaml:"local,omitempty"`
 }
 
 type EncryptionConfig struct {
-	AwsKms *AwsKmsBackendConfig `json:"awsKms,omitempty" yaml:"awsKms,omitempty"`
+	AwsKms     *AwsKmsBackendConfig     `json:"awsKms,omitempty" yaml:"awsKms,omitempty"`
+	Age        *AgeBackendConfig        `json:"age,omitempty" yaml:"age,omitempty"`
+	Passphrase *PassphraseBackendConfig `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
 }
 
 type AwsKmsBackendConfig struct {
@@ -21,3 +24,19 @@ type AwsKmsBackendConfig struct {
 type AwsS3BackendConfig struct {
 	Bucket string `json:"bucket" yaml:"bucket"`
 }
+
+type LocalBackendConfig struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+type AgeBackendConfig struct {
+	Recipients     []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+	RecipientsFile string   `json:"recipientsFile,omitempty" yaml:"recipientsFile,omitempty"`
+	IdentityEnv    string   `json:"identityEnv,omitempty" yaml:"identityEnv,omitempty"`
+	IdentityFile   string   `json:"identityFile,omitempty" yaml:"identityFile,omitempty"`
+}
+
+type PassphraseBackendConfig struct {
+	PassphraseEnv  string `json:"passphraseEnv,omitempty" yaml:"passphraseEnv,omitempty"`
+	PassphraseFile string `json:"passphraseFile,omitempty" yaml:"passphraseFile,omitempty"`
+}
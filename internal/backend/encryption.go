@@ -13,7 +13,21 @@ type Encryption interface {
 }
 
 func NewEncryption(ctx context.Context, config EncryptionConfig, awsConfig aws.Config) (Encryption, error) {
-	if config.AwsKms != nil {
+	configured := 0
+	for _, c := range []bool{config.AwsKms != nil, config.Age != nil, config.Passphrase != nil} {
+		if c {
+			configured++
+		}
+	}
+	if configured > 1 {
+		return nil, fmt.Errorf("multiple backend encryptions configured, configure one of awsKms, age or passphrase")
+	}
+
+	if config.Age != nil {
+		return NewAgeEncryption(*config.Age)
+	} else if config.Passphrase != nil {
+		return NewPassphraseEncryption(*config.Passphrase)
+	} else if config.AwsKms != nil {
 		encryption := &AwsKmsEncryption{
 			Context:   ctx,
 			Config:    *config.AwsKms,
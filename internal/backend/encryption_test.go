@@ -0,0 +1,131 @@
+package backend_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/dotnetmentor/racoon/internal/backend"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encryption", func() {
+	roundtrip := func(encryption backend.Encryption) {
+		encrypted, err := encryption.Encrypt([]byte("s3cr3t"))
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(string(encrypted)).ToNot(ContainSubstring("s3cr3t"))
+
+		decrypted, err := encryption.Decrypt(encrypted)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(string(decrypted)).To(Equal("s3cr3t"))
+	}
+
+	It("returns error when multiple encryptions are configured", func() {
+		_, err := backend.NewEncryption(context.Background(), backend.EncryptionConfig{
+			Age:        &backend.AgeBackendConfig{},
+			Passphrase: &backend.PassphraseBackendConfig{},
+		}, awsConfigNotUsed)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("age", func() {
+		var identity *age.X25519Identity
+
+		BeforeEach(func() {
+			var err error
+			identity, err = age.GenerateX25519Identity()
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("RACOON_AGE_IDENTITY")
+		})
+
+		It("encrypts for the recipient of the identity by default", func() {
+			os.Setenv("RACOON_AGE_IDENTITY", identity.String())
+			encryption, err := backend.NewEncryption(context.Background(), backend.EncryptionConfig{Age: &backend.AgeBackendConfig{}}, awsConfigNotUsed)
+			Expect(err).To(Not(HaveOccurred()))
+			roundtrip(encryption)
+		})
+
+		It("encrypts for configured recipients and decrypts using an identity file", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "identity.txt")
+			Expect(os.WriteFile(file, []byte("# test identity\n"+identity.String()+"\n"), 0600)).To(Succeed())
+
+			encryption, err := backend.NewAgeEncryption(backend.AgeBackendConfig{
+				Recipients:   []string{identity.Recipient().String()},
+				IdentityFile: file,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+			roundtrip(encryption)
+		})
+
+		It("encrypts without an identity, but can not decrypt", func() {
+			encryption, err := backend.NewAgeEncryption(backend.AgeBackendConfig{Recipients: []string{identity.Recipient().String()}})
+			Expect(err).To(Not(HaveOccurred()))
+
+			encrypted, err := encryption.Encrypt([]byte("s3cr3t"))
+			Expect(err).To(Not(HaveOccurred()))
+			_, err = encryption.Decrypt(encrypted)
+			Expect(err).To(MatchError(ContainSubstring("RACOON_AGE_IDENTITY")))
+		})
+
+		It("returns error without recipients and identity", func() {
+			_, err := backend.NewAgeEncryption(backend.AgeBackendConfig{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("passphrase", func() {
+		AfterEach(func() {
+			os.Unsetenv("RACOON_BACKEND_PASSPHRASE")
+			os.Unsetenv("OTHER_PASSPHRASE")
+		})
+
+		It("encrypts using a passphrase from the environment", func() {
+			os.Setenv("RACOON_BACKEND_PASSPHRASE", "correct horse battery staple")
+			encryption, err := backend.NewEncryption(context.Background(), backend.EncryptionConfig{Passphrase: &backend.PassphraseBackendConfig{}}, awsConfigNotUsed)
+			Expect(err).To(Not(HaveOccurred()))
+			roundtrip(encryption)
+		})
+
+		It("decrypts values encrypted by another instance using a passphrase file", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "passphrase")
+			Expect(os.WriteFile(file, []byte("correct horse battery staple\n"), 0600)).To(Succeed())
+			os.Setenv("OTHER_PASSPHRASE", "correct horse battery staple")
+
+			encryption, err := backend.NewPassphraseEncryption(backend.PassphraseBackendConfig{PassphraseEnv: "OTHER_PASSPHRASE"})
+			Expect(err).To(Not(HaveOccurred()))
+			encrypted, err := encryption.Encrypt([]byte("s3cr3t"))
+			Expect(err).To(Not(HaveOccurred()))
+
+			other, err := backend.NewPassphraseEncryption(backend.PassphraseBackendConfig{PassphraseFile: file})
+			Expect(err).To(Not(HaveOccurred()))
+			decrypted, err := other.Decrypt(encrypted)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(string(decrypted)).To(Equal("s3cr3t"))
+		})
+
+		It("returns error when decrypting using another passphrase", func() {
+			os.Setenv("RACOON_BACKEND_PASSPHRASE", "correct horse battery staple")
+			encryption, err := backend.NewPassphraseEncryption(backend.PassphraseBackendConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			encrypted, err := encryption.Encrypt([]byte("s3cr3t"))
+			Expect(err).To(Not(HaveOccurred()))
+
+			os.Setenv("RACOON_BACKEND_PASSPHRASE", "incorrect")
+			other, err := backend.NewPassphraseEncryption(backend.PassphraseBackendConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			_, err = other.Decrypt(encrypted)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns error when the passphrase is not set", func() {
+			_, err := backend.NewPassphraseEncryption(backend.PassphraseBackendConfig{})
+			Expect(err).To(MatchError(ContainSubstring("RACOON_BACKEND_PASSPHRASE")))
+		})
+	})
+})
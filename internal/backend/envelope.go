@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+const (
+	EnvelopeVersion = 1
+	EnvelopeCipher  = "aes-256-gcm"
+
+	envelopeKeySize = 32
+)
+
+// DataKeyGenerator is implemented by encryptions generating data keys themselves (example: kms), the wrapped
+// key must be possible to unwrap using Decrypt
+type DataKeyGenerator interface {
+	GenerateDataKey() (plain []byte, wrapped []byte, err error)
+}
+
+// NewDataKey returns a data key and the data key wrapped by the encryption, keys are generated locally
+// and wrapped using Encrypt unless generated by the encryption
+func NewDataKey(encryption Encryption) (plain []byte, wrapped []byte, err error) {
+	if g, ok := encryption.(DataKeyGenerator); ok {
+		return g.GenerateDataKey()
+	}
+
+	plain = make([]byte, envelopeKeySize)
+	if _, err := io.ReadFull(rand.Reader, plain); err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err = encryption.Encrypt(plain)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plain, wrapped, nil
+}
+
+// Envelope encrypts values locally using a data key, only the data key is encrypted by the backend encryption
+type Envelope struct {
+	aead cipher.AEAD
+}
+
+func NewEnvelope(key []byte) (*Envelope, error) {
+	if len(key) != envelopeKeySize {
+		return nil, fmt.Errorf("invalid data key, expected %d bytes but got %d", envelopeKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{aead: aead}, nil
+}
+
+// Seal encrypts a value bound to a name, the value can only be opened using the same name
+func (e *Envelope) Seal(name string, v []byte) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(e.aead.Seal(nonce, nonce, v, []byte(name))), nil
+}
+
+func (e *Envelope) Open(name string, v string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(v)
+	if err != nil || len(sealed) < e.aead.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted value")
+	}
+	return e.aead.Open(nil, sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():], []byte(name))
+}
@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalBackendStore stores files in a directory on disk, keys are paths relative to the directory
+type LocalBackendStore struct {
+	Config LocalBackendConfig
+}
+
+func (b LocalBackendStore) Upload(key string, body []byte) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// written to a temporary file and renamed, readers never see partially written files
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (b LocalBackendStore) Download(key string) ([]byte, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (b LocalBackendStore) List(prefix string) ([]string, error) {
+	// only the directory of the prefix is walked
+	root := b.Config.Path
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dir, err := b.path(prefix[:i])
+		if err != nil {
+			return nil, err
+		}
+		root = dir
+	}
+
+	keys := make([]string, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.Config.Path, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// path returns the path of a key, keys must not point outside of the directory
+func (b LocalBackendStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if len(key) == 0 || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid key %s, keys must be relative paths within the local backend store", key)
+	}
+	return filepath.Join(b.Config.Path, clean), nil
+}
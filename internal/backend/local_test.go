@@ -0,0 +1,91 @@
+package backend_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/dotnetmentor/racoon/internal/backend"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LocalBackendStore", func() {
+	var dir string
+	var store backend.Store
+
+	BeforeEach(func() {
+		dir = filepath.Join(GinkgoT().TempDir(), "backend")
+		var err error
+		store, err = backend.NewStore(context.Background(), backend.StoreConfig{Local: &backend.LocalBackendConfig{Path: dir}}, awsConfigNotUsed)
+		Expect(err).To(Not(HaveOccurred()))
+	})
+
+	It("lists nothing before uploading", func() {
+		keys, err := store.List("")
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(keys).To(BeEmpty())
+	})
+
+	It("uploads, downloads and lists files", func() {
+		Expect(store.Upload("context/dev/myapp/racoon.config", []byte("dev"))).To(Succeed())
+		Expect(store.Upload("context/prod/myapp/racoon.config", []byte("prod"))).To(Succeed())
+		Expect(store.Upload("context/dev/myapp/racoon.config", []byte("dev2"))).To(Succeed())
+
+		b, err := store.Download("context/dev/myapp/racoon.config")
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(string(b)).To(Equal("dev2"))
+
+		keys, err := store.List("")
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(keys).To(Equal([]string{"context/dev/myapp/racoon.config", "context/prod/myapp/racoon.config"}))
+	})
+
+	It("lists files starting with a prefix", func() {
+		Expect(store.Upload("context/dev/myapp/racoon.config", []byte("dev"))).To(Succeed())
+		Expect(store.Upload("context/dev/myapp/history/1.config", []byte("1"))).To(Succeed())
+		Expect(store.Upload("context/dev/myapp2/racoon.config", []byte("dev"))).To(Succeed())
+		Expect(store.Upload("context/prod/myapp/racoon.config", []byte("prod"))).To(Succeed())
+
+		keys, err := store.List("context/dev/myapp/")
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(keys).To(Equal([]string{"context/dev/myapp/history/1.config", "context/dev/myapp/racoon.config"}))
+
+		keys, err = store.List("context/dev/myapp")
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(keys).To(HaveLen(3))
+
+		keys, err = store.List("context/test/")
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(keys).To(BeEmpty())
+	})
+
+	It("returns error when downloading missing files", func() {
+		_, err := store.Download("context/dev/myapp/racoon.config")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("rejects keys outside of the directory", func() {
+		Expect(store.Upload("../escaped", []byte("x"))).To(HaveOccurred())
+		_, err := store.Download("/etc/passwd")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("New", func() {
+	It("returns error when multiple stores are configured", func() {
+		_, err := backend.NewStore(context.Background(), backend.StoreConfig{
+			AwsS3: &backend.AwsS3BackendConfig{Bucket: "bucket"},
+			Local: &backend.LocalBackendConfig{Path: "."},
+		}, awsConfigNotUsed)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("selects encryption independently of the store", func() {
+		_, err := backend.New(context.Background(), backend.BackendConfig{
+			Store: backend.StoreConfig{Local: &backend.LocalBackendConfig{Path: GinkgoT().TempDir()}},
+		})
+		Expect(err).To(MatchError("encryption not configured"))
+	})
+})
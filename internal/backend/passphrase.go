@@ -0,0 +1,137 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dotnetmentor/racoon/internal/environment"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	passphraseDefaultEnv = "RACOON_BACKEND_PASSPHRASE"
+	passphraseVersion    = byte(1)
+	passphraseSaltSize   = 16
+
+	// scrypt parameters recommended for interactive use (2017)
+	passphraseScryptN = 1 << 15
+	passphraseScryptR = 8
+	passphraseScryptP = 1
+)
+
+// PassphraseEncryption encrypts values using XChaCha20-Poly1305 and a key derived from a passphrase using scrypt.
+// Values encrypted by the same instance share a salt, deriving the key once rather than once per value.
+type PassphraseEncryption struct {
+	Config     PassphraseBackendConfig
+	passphrase []byte
+
+	mu   sync.Mutex
+	salt []byte
+	keys map[string][]byte
+}
+
+func NewPassphraseEncryption(config PassphraseBackendConfig) (*PassphraseEncryption, error) {
+	env := passphraseDefaultEnv
+	if len(config.PassphraseEnv) > 0 {
+		env = config.PassphraseEnv
+	}
+
+	passphrase := environment.StringVar(env, "")
+	if len(passphrase) == 0 && len(config.PassphraseFile) > 0 {
+		b, err := os.ReadFile(config.PassphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file, %v", err)
+		}
+		passphrase = strings.TrimRight(string(b), "\r\n")
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase not set, set environment variable %s or backend.encryption.passphrase.passphraseFile", env)
+	}
+
+	return &PassphraseEncryption{
+		Config:     config,
+		passphrase: []byte(passphrase),
+		keys:       make(map[string][]byte),
+	}, nil
+}
+
+func (encryption *PassphraseEncryption) Encrypt(v []byte) ([]byte, error) {
+	encryption.mu.Lock()
+	if encryption.salt == nil {
+		salt := make([]byte, passphraseSaltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			encryption.mu.Unlock()
+			return nil, err
+		}
+		encryption.salt = salt
+	}
+	salt := encryption.salt
+	encryption.mu.Unlock()
+
+	aead, err := encryption.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	// version | salt | nonce | ciphertext
+	out := append([]byte{passphraseVersion}, salt...)
+	out = append(out, nonce...)
+	out = aead.Seal(out, nonce, v, []byte{passphraseVersion})
+
+	return []byte(base64.StdEncoding.EncodeToString(out)), nil
+}
+
+func (encryption *PassphraseEncryption) Decrypt(v []byte) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(string(v))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < 1+passphraseSaltSize+chacha20poly1305.NonceSizeX || b[0] != passphraseVersion {
+		return nil, fmt.Errorf("unsupported or malformed passphrase encrypted value")
+	}
+
+	salt := b[1 : 1+passphraseSaltSize]
+	nonce := b[1+passphraseSaltSize : 1+passphraseSaltSize+chacha20poly1305.NonceSizeX]
+	ciphertext := b[1+passphraseSaltSize+chacha20poly1305.NonceSizeX:]
+
+	aead, err := encryption.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte{passphraseVersion})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value, the passphrase does not match the passphrase used for encryption")
+	}
+	return plain, nil
+}
+
+func (encryption *PassphraseEncryption) aead(salt []byte) (cipher.AEAD, error) {
+	encryption.mu.Lock()
+	defer encryption.mu.Unlock()
+
+	key, ok := encryption.keys[string(salt)]
+	if !ok {
+		k, err := scrypt.Key(encryption.passphrase, salt, passphraseScryptN, passphraseScryptR, passphraseScryptP, chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, err
+		}
+		key = k
+		encryption.keys[string(salt)] = key
+	}
+
+	return chacha20poly1305.NewX(key)
+}
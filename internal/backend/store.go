@@ -10,11 +10,24 @@ import (
 type Store interface {
 	Upload(key string, body []byte) error
 	Download(key string) ([]byte, error)
-	List() ([]string, error)
+	// List returns the keys starting with prefix, all keys when prefix is empty
+	List(prefix string) ([]string, error)
 }
 
 func NewStore(ctx context.Context, config StoreConfig, awsConfig aws.Config) (Store, error) {
-	if config.AwsS3 != nil {
+	if config.AwsS3 != nil && config.Local != nil {
+		return nil, fmt.Errorf("multiple backend stores configured, configure one of awsS3 or local")
+	}
+
+	if config.Local != nil {
+		if len(config.Local.Path) == 0 {
+			return nil, fmt.Errorf("local backend store path not set")
+		}
+		store := &LocalBackendStore{
+			Config: *config.Local,
+		}
+		return store, nil
+	} else if config.AwsS3 != nil {
 		store := &AwsS3BackendStore{
 			Context:   ctx,
 			Config:    *config.AwsS3,
@@ -0,0 +1,162 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/backend"
+	"github.com/dotnetmentor/racoon/internal/config"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	backendFormatText = "text"
+	backendFormatJson = "json"
+)
+
+func Backend(metadata config.AppMetadata) *cli.Command {
+	return &cli.Command{
+		Name:  "backend",
+		Usage: "Browses configs exported to the backend",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "history",
+				Usage: "Lists the versions of the config exported using the given parameters, latest first",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:    "parameter",
+						Aliases: []string{"p"},
+						Usage:   "sets layer parameters",
+					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "limits the number of versions listed, 0 lists all versions",
+						Value: 20,
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "sets the output format (text, json)",
+						Value:   backendFormatText,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					format := c.String("format")
+					if format != backendFormatText && format != backendFormatJson {
+						return fmt.Errorf("unsupported format %s, must be one of %s or %s", format, backendFormatText, backendFormatJson)
+					}
+
+					ctx, b, err := backendContext(c, metadata)
+					if err != nil {
+						return err
+					}
+
+					encconf := api.NewEncryptedConfig(ctx, b)
+					versions, err := encconf.Versions(b.Store())
+					if err != nil {
+						return err
+					}
+					if limit := c.Int("limit"); limit > 0 && len(versions) > limit {
+						versions = versions[:limit]
+					}
+
+					history := make([]api.SnapshotMetadata, 0)
+					for _, v := range versions {
+						snapshot, err := encconf.Load(b.Store(), v)
+						if err != nil {
+							return err
+						}
+						if snapshot.Snapshot == nil {
+							history = append(history, api.SnapshotMetadata{Version: v})
+							continue
+						}
+						history = append(history, *snapshot.Snapshot)
+					}
+
+					if format == backendFormatJson {
+						enc := json.NewEncoder(c.App.Writer)
+						enc.SetIndent("", "  ")
+						return enc.Encode(history)
+					}
+
+					if len(history) == 0 {
+						fmt.Fprintf(c.App.Writer, "no versions found for %s\n", encconf.Path())
+						return nil
+					}
+
+					w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "VERSION\tCREATED\tUSER\tHOSTNAME\tRACOON")
+					for _, s := range history {
+						created := ""
+						if !s.CreatedAt.IsZero() {
+							created = s.CreatedAt.Format("2006-01-02 15:04:05 MST")
+						}
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Version, created, s.User, s.Hostname, s.RacoonVersion)
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:  "show",
+				Usage: "Shows the config exported using the given parameters",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:    "parameter",
+						Aliases: []string{"p"},
+						Usage:   "sets layer parameters",
+					},
+					&cli.StringFlag{
+						Name:  "version",
+						Usage: "shows a version listed by the history command, defaults to the latest version",
+					},
+					&cli.BoolFlag{
+						Name:  "decrypt",
+						Usage: "decrypts sensitive values",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					ctx, b, err := backendContext(c, metadata)
+					if err != nil {
+						return err
+					}
+
+					encconf := api.NewEncryptedConfig(ctx, b)
+					snapshot, err := encconf.Load(b.Store(), c.String("version"))
+					if err != nil {
+						return err
+					}
+
+					if c.Bool("decrypt") {
+						if err := snapshot.Decrypt(b.Encryption()); err != nil {
+							return err
+						}
+					}
+
+					enc := json.NewEncoder(c.App.Writer)
+					enc.SetIndent("", "  ")
+					return enc.Encode(snapshot)
+				},
+			},
+		},
+	}
+}
+
+// backendContext creates the context and backend used by backend commands, failing when the backend is not enabled
+func backendContext(c *cli.Context, metadata config.AppMetadata) (config.AppContext, backend.Backend, error) {
+	ctx, err := newContext(c, metadata, true)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	b, err := newBackend(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	if b == nil {
+		return ctx, nil, fmt.Errorf("backend not enabled, enable it in the manifest or using RACOON_BACKEND_ENABLED=true")
+	}
+	return ctx, b, nil
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -100,6 +101,74 @@ func Config(metadata config.AppMetadata) *cli.Command {
 					return nil
 				},
 			},
+			{
+				Name:      "lint",
+				Usage:     "Statically inspects the configuration for problems, without reading from any source",
+				UsageText: "",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "sets the output format (text, json)",
+						Value:   "text",
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "fails on warnings",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					format := c.String("format")
+					if format != "text" && format != "json" {
+						return fmt.Errorf("unsupported format %s, must be one of text or json", format)
+					}
+
+					ctx, err := newContext(c, metadata, false)
+					if err != nil {
+						return err
+					}
+
+					issues := config.Lint(ctx.Manifest)
+
+					errors, warnings := 0, 0
+					for _, i := range issues {
+						switch i.Severity {
+						case config.LintSeverityError:
+							errors++
+						case config.LintSeverityWarning:
+							warnings++
+						}
+					}
+
+					if format == "json" {
+						enc := json.NewEncoder(c.App.Writer)
+						enc.SetIndent("", "  ")
+						if err := enc.Encode(issues); err != nil {
+							return err
+						}
+					} else {
+						for _, i := range issues {
+							fmt.Fprintf(c.App.Writer, "%-7s %s: %s (%s)\n", i.Severity, i.Path, i.Message, i.Rule)
+						}
+						fmt.Fprintf(c.App.Writer, "%d error(s), %d warning(s)\n", errors, warnings)
+					}
+
+					if errors > 0 || (c.Bool("strict") && warnings > 0) {
+						return fmt.Errorf("lint failed, %d error(s) and %d warning(s) found", errors, warnings)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "schema",
+				Usage:     "Prints the JSON Schema of the manifest",
+				UsageText: "",
+				Action: func(c *cli.Context) error {
+					enc := json.NewEncoder(c.App.Writer)
+					enc.SetIndent("", "  ")
+					return enc.Encode(config.Schema())
+				},
+			},
 			{
 				Name:      "show",
 				Usage:     "Shows the current configuration",
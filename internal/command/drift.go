@@ -0,0 +1,214 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/utils"
+	"github.com/dotnetmentor/racoon/internal/visitor"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	driftFormatText = "text"
+	driftFormatJson = "json"
+
+	// driftExitCode is the exit code used when drift is detected, errors exit using 1
+	driftExitCode = 2
+)
+
+type driftReport struct {
+	Version    string              `json:"version,omitempty"`
+	Path       string              `json:"path"`
+	Drifted    bool                `json:"drifted"`
+	Properties []api.PropertyDrift `json:"properties"`
+}
+
+func Drift(metadata config.AppMetadata) *cli.Command {
+	return &cli.Command{
+		Name:  "drift",
+		Usage: "Compares the currently resolved values to the config last exported to the backend",
+		Description: fmt.Sprintf("Exits using %d when values have drifted from the exported config and 1 on errors. "+
+			"Sensitive values are decrypted in memory and never printed, use --hashes to compare them using keyed hashes.", driftExitCode),
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "parameter",
+				Aliases: []string{"p"},
+				Usage:   "sets layer parameters",
+			},
+			&cli.StringFlag{
+				Name:  "version",
+				Usage: "compares to a version listed by the backend history command, defaults to the latest version",
+			},
+			&cli.StringSliceFlag{
+				Name:    "include",
+				Aliases: []string{"i"},
+				Usage:   "include property in comparison",
+			},
+			&cli.StringSliceFlag{
+				Name:    "exclude",
+				Aliases: []string{"e"},
+				Usage:   "exclude property from comparison",
+			},
+			&cli.BoolFlag{
+				Name:  "hashes",
+				Usage: "shows hashes of changed values, including sensitive values, keyed using the data key of the exported config",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "sets the output format (text, json)",
+				Value:   driftFormatText,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if format != driftFormatText && format != driftFormatJson {
+				return fmt.Errorf("unsupported format %s, must be one of %s or %s", format, driftFormatText, driftFormatJson)
+			}
+
+			ctx, b, err := backendContext(c, metadata)
+			if err != nil {
+				return err
+			}
+
+			excludes := c.StringSlice("exclude")
+			includes := c.StringSlice("include")
+
+			encconf := api.NewEncryptedConfig(ctx, b)
+			snapshot, err := encconf.Load(b.Store(), c.String("version"))
+			if err != nil {
+				return err
+			}
+			if err := snapshot.Decrypt(b.Encryption()); err != nil {
+				return err
+			}
+
+			// properties filtered from the current values are filtered from the snapshot as well, and properties
+			// filtered when the snapshot was exported are filtered from the current values
+			filtered := snapshot.Properties[:0]
+			for _, p := range snapshot.Properties {
+				if (len(includes) > 0 && !utils.StringSliceContains(includes, p.Name)) || utils.StringSliceContains(excludes, p.Name) {
+					continue
+				}
+				filtered = append(filtered, p)
+			}
+			snapshot.Properties = filtered
+			if s := snapshot.Snapshot; s != nil && (len(s.Excludes) > 0 || len(s.Includes) > 0) {
+				ctx.Log.Infof("comparing properties exported using filters (excludes=%v includes=%v)", s.Excludes, s.Includes)
+			}
+
+			visit := visitor.New(ctx)
+			if err := visit.Init(excludes, includes); err != nil {
+				return err
+			}
+
+			current := make([]api.Property, 0)
+			err = visit.Property(func(p api.Property, err error) (bool, error) {
+				if err != nil {
+					return false, err
+				}
+				// values not found are compared as not set, other errors must not be reported as drift
+				if v := p.Value(); v != nil && v.Error() != nil && !api.IsNotFoundError(v.Error()) {
+					return false, fmt.Errorf("unable to resolve value of property %s from %s, %v", p.Name, v.SourceAndKey(), v.Error())
+				}
+				if snapshot.Snapshot.Tracked(p.Name) {
+					current = append(current, p)
+				}
+				return true, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			drift, err := api.Drift(snapshot, current, c.Bool("hashes"))
+			if err != nil {
+				return err
+			}
+
+			report := driftReport{
+				Path:       encconf.Path(),
+				Drifted:    len(drift) > 0,
+				Properties: drift,
+			}
+			if snapshot.Snapshot != nil {
+				report.Version = snapshot.Snapshot.Version
+			}
+			if len(c.String("version")) > 0 {
+				report.Path = encconf.VersionPath(c.String("version"))
+			}
+
+			if format == driftFormatJson {
+				enc := json.NewEncoder(c.App.Writer)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else if err := writeDriftReport(c.App.Writer, report, c.Bool("hashes")); err != nil {
+				return err
+			}
+
+			if report.Drifted {
+				c.App.Metadata[MetadataExitCode] = driftExitCode
+			}
+			return nil
+		},
+	}
+}
+
+func writeDriftReport(w io.Writer, report driftReport, hashes bool) error {
+	exported := report.Path
+	if len(report.Version) > 0 {
+		exported = fmt.Sprintf("version %s", report.Version)
+	}
+
+	if !report.Drifted {
+		fmt.Fprintf(w, "no drift from %s\n", exported)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, d := range report.Properties {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Status, d.Name, driftChange(d, hashes))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%d property(ies) drifted from %s\n", len(report.Properties), exported)
+	return nil
+}
+
+// driftChange formats the values of a drifted property, sensitive values are shown as hashes when included
+func driftChange(d api.PropertyDrift, hashes bool) string {
+	if d.Sensitive && !hashes {
+		return maskedValue
+	}
+
+	format := func(v *string, hash string) string {
+		switch {
+		case d.Sensitive && len(hash) > 0:
+			return hash
+		case v == nil:
+			return "<not set>"
+		case hashes:
+			return fmt.Sprintf("%q (%s)", *v, hash)
+		default:
+			return fmt.Sprintf("%q", *v)
+		}
+	}
+
+	switch d.Status {
+	case api.DriftStatusAdded:
+		return format(d.CurrentValue, d.CurrentHash)
+	case api.DriftStatusRemoved:
+		return format(d.SnapshotValue, d.SnapshotHash)
+	default:
+		return fmt.Sprintf("%s -> %s", format(d.SnapshotValue, d.SnapshotHash), format(d.CurrentValue, d.CurrentHash))
+	}
+}
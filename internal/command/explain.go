@@ -0,0 +1,316 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/visitor"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	explainFormatText = "text"
+	explainFormatJson = "json"
+
+	explainStatusOk       = "ok"
+	explainStatusNotFound = "not found"
+	explainStatusInvalid  = "invalid"
+	explainStatusError    = "error"
+
+	maskedValue = "<sensitive>"
+)
+
+type explainedProperty struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Sensitive   bool             `json:"sensitive"`
+	DefinedIn   string           `json:"definedIn"`
+	Layers      []explainedLayer `json:"layers"`
+	Values      []explainedValue `json:"values"`
+	Result      *explainedValue  `json:"result"`
+	Error       string           `json:"error,omitempty"`
+}
+
+type explainedLayer struct {
+	Name    string `json:"name"`
+	Defines bool   `json:"defines"`
+}
+
+type explainedValue struct {
+	Layer      string   `json:"layer"`
+	Source     string   `json:"source"`
+	Key        string   `json:"key,omitempty"`
+	Value      string   `json:"value"`
+	Sensitive  bool     `json:"sensitive"`
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+	Formatters []string `json:"formatters,omitempty"`
+	Selected   bool     `json:"selected"`
+}
+
+func Explain(metadata config.AppMetadata) *cli.Command {
+	return &cli.Command{
+		Name:      "explain",
+		Usage:     "Explains how the values of properties are resolved across layers",
+		UsageText: "racoon explain [command options] [property...]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "parameter",
+				Aliases: []string{"p"},
+				Usage:   "sets layer parameters",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "sets the output format (text, json)",
+				Value:   explainFormatText,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if format != explainFormatText && format != explainFormatJson {
+				return fmt.Errorf("unsupported format %s, must be one of %s or %s", format, explainFormatText, explainFormatJson)
+			}
+
+			includes := make([]string, 0)
+			for _, a := range c.Args().Slice() {
+				includes = append(includes, strings.TrimSpace(a))
+			}
+
+			ctx, err := newContext(c, metadata, true)
+			if err != nil {
+				return err
+			}
+
+			visit := visitor.New(ctx)
+
+			err = visit.Init([]string{}, includes)
+			if err != nil {
+				return err
+			}
+
+			layers := make([]api.Layer, 0)
+			err = visit.Layer(func(l api.Layer, err error) (bool, error) {
+				if err != nil {
+					return false, err
+				}
+				layers = append(layers, l)
+				return true, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			explained := make([]explainedProperty, 0)
+			err = visit.Property(func(p api.Property, err error) (bool, error) {
+				if err != nil {
+					return false, err
+				}
+				explained = append(explained, explainProperty(p, layers))
+				return true, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, name := range includes {
+				found := false
+				for _, e := range explained {
+					if e.Name == name {
+						found = true
+						break
+					}
+				}
+				if !found {
+					ctx.Log.Warnf("property %s not found", name)
+				}
+			}
+
+			if format == explainFormatJson {
+				enc := json.NewEncoder(c.App.Writer)
+				enc.SetIndent("", "  ")
+				return enc.Encode(explained)
+			}
+
+			for i, e := range explained {
+				if i > 0 {
+					fmt.Fprintln(c.App.Writer)
+				}
+				writeExplainedProperty(c.App.Writer, e)
+			}
+			return nil
+		},
+	}
+}
+
+func explainProperty(p api.Property, layers []api.Layer) explainedProperty {
+	e := explainedProperty{
+		Name:        p.Name,
+		Description: p.Description,
+		Sensitive:   p.Sensitive(),
+		DefinedIn:   p.Source(),
+		Layers:      make([]explainedLayer, 0),
+		Values:      make([]explainedValue, 0),
+	}
+
+	for _, l := range layers {
+		e.Layers = append(e.Layers, explainedLayer{
+			Name:    l.Name,
+			Defines: l.Property(p.Name) != nil,
+		})
+	}
+
+	selected := p.Value()
+	for _, v := range p.Values() {
+		ev := explainValue(p, v)
+		ev.Selected = v == selected
+
+		// formatters are defined per layer, the layer of a value source is captured before its properties are loaded
+		if v.Source().Type() == api.SourceTypeFormatter {
+			for _, l := range layers {
+				if l.Name != ev.Layer {
+					continue
+				}
+				if lp := l.Property(p.Name); lp != nil {
+					for _, fc := range lp.Formatting() {
+						ev.Formatters = append(ev.Formatters, describeFormatter(fc))
+					}
+				}
+			}
+		}
+
+		e.Values = append(e.Values, ev)
+	}
+
+	for i := range e.Values {
+		if e.Values[i].Selected {
+			e.Result = &e.Values[i]
+		}
+	}
+
+	if err := p.Validate(selected); err != nil {
+		e.Error = err.Error()
+	}
+
+	return e
+}
+
+func explainValue(p api.Property, v api.Value) explainedValue {
+	ev := explainedValue{
+		Layer:     v.Source().Layer().Name,
+		Source:    string(v.Source().Type()),
+		Key:       v.Key(),
+		Value:     v.Raw(),
+		Sensitive: v.Sensitive(),
+		Status:    explainStatusOk,
+	}
+
+	if v.Sensitive() {
+		ev.Value = maskedValue
+	}
+
+	switch {
+	case api.IsNotFoundError(v.Error()):
+		ev.Status = explainStatusNotFound
+	case v.Error() != nil:
+		ev.Status = explainStatusError
+		ev.Error = v.Error().Error()
+	default:
+		if err := p.Validate(v); err != nil {
+			ev.Status = explainStatusInvalid
+			ev.Error = err.Error()
+		}
+	}
+
+	if ev.Status != explainStatusOk && ev.Status != explainStatusInvalid {
+		ev.Value = ""
+	}
+
+	return ev
+}
+
+func describeFormatter(fc config.FormattingConfig) string {
+	var desc string
+	switch {
+	case fc.Replace != nil:
+		desc = fmt.Sprintf("replace {%s}", *fc.Replace)
+	case fc.RegexpReplace != nil:
+		desc = fmt.Sprintf("regexpReplace %s", *fc.RegexpReplace)
+	default:
+		desc = "unknown"
+	}
+	if fc.Source != nil {
+		desc += fmt.Sprintf(" from %s", fc.Source.SourceType())
+	}
+	if fc.Optional != nil && *fc.Optional {
+		desc += " (optional)"
+	}
+	return desc
+}
+
+func writeExplainedProperty(w io.Writer, e explainedProperty) {
+	fmt.Fprintf(w, "%s\n", e.Name)
+	fmt.Fprintf(w, "  description: %s\n", e.Description)
+	fmt.Fprintf(w, "  sensitive:   %v\n", e.Sensitive)
+	fmt.Fprintf(w, "  defined in:  %s\n", e.DefinedIn)
+
+	layers := make([]string, 0)
+	for _, l := range e.Layers {
+		if l.Defines {
+			layers = append(layers, l.Name)
+		} else {
+			layers = append(layers, fmt.Sprintf("(%s)", l.Name))
+		}
+	}
+	fmt.Fprintf(w, "  layers:      %s\n", strings.Join(layers, " -> "))
+
+	fmt.Fprintf(w, "  values:\n")
+	if len(e.Values) == 0 {
+		fmt.Fprintf(w, "    none\n")
+	}
+	for _, v := range e.Values {
+		marker := " "
+		if v.Selected {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "  %s %s/%s", marker, v.Layer, v.Source)
+		if len(v.Key) > 0 {
+			fmt.Fprintf(w, " (key=%s)", v.Key)
+		}
+		fmt.Fprintf(w, ": %s", explainedValueString(v))
+		if len(v.Error) > 0 {
+			fmt.Fprintf(w, ", %s", v.Error)
+		}
+		fmt.Fprintln(w)
+		for _, f := range v.Formatters {
+			fmt.Fprintf(w, "        formatter: %s\n", f)
+		}
+	}
+
+	if e.Result != nil {
+		fmt.Fprintf(w, "  result:      %s from %s/%s\n", explainedValueString(*e.Result), e.Result.Layer, e.Result.Source)
+	} else {
+		fmt.Fprintf(w, "  result:      <none>\n")
+	}
+	if len(e.Error) > 0 {
+		fmt.Fprintf(w, "  error:       %s\n", e.Error)
+	}
+}
+
+func explainedValueString(v explainedValue) string {
+	switch {
+	case v.Status == explainStatusNotFound:
+		return "<not found>"
+	case v.Status == explainStatusError:
+		return "<error>"
+	case len(v.Value) == 0:
+		return "<empty>"
+	default:
+		return v.Value
+	}
+}
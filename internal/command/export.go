@@ -2,14 +2,13 @@ package command
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/dotnetmentor/racoon/internal/api"
 	"github.com/dotnetmentor/racoon/internal/config"
 	"github.com/dotnetmentor/racoon/internal/output"
-	"github.com/dotnetmentor/racoon/internal/utils"
 	"github.com/dotnetmentor/racoon/internal/visitor"
 
 	"github.com/urfave/cli/v2"
@@ -70,15 +69,13 @@ func Export(metadata config.AppMetadata) *cli.Command {
 				return nil
 			}
 
-			keys := []string{}
-			values := map[string]api.Value{}
-
 			backend, err := newBackend(ctx)
 			if err != nil {
 				return err
 			}
 
 			encconf := api.NewEncryptedConfig(ctx, backend)
+			encconf.Filter(excludes, includes)
 
 			visit := visitor.New(ctx)
 
@@ -87,46 +84,10 @@ func Export(metadata config.AppMetadata) *cli.Command {
 				return err
 			}
 
-			err = visit.Property(func(p api.Property, err error) (bool, error) {
-				if err != nil {
-					return false, err
-				}
-
-				if err := encconf.Track(p); err != nil {
-					return false, err
-				}
-
-				key := p.Name
-
-				if !utils.StringSliceContains(keys, key) {
-					keys = append(keys, key)
-				}
-
-				val := p.Value()
-				if err := p.Validate(val); err != nil {
-					return false, err
-				}
-
-				// If validation passes but the value is nil, continue
-				if val == nil {
-					return true, nil
-				}
-
-				// If validation passes but we have a not found error for the resolved value, skip export
-				if !api.IsNotFoundError(val.Error()) {
-					values[key] = val
-				}
-
-				ctx.Log.Infof("property %s, defined in %s, value from %s, value set to: %s", p.Name, p.Source(), val.Source(), val.String())
-				for _, v := range p.Values() {
-					if err := p.Validate(v); err != nil {
-						ctx.Log.Debugf("- value from %s is invalid, err: %v", v.Source(), err)
-					} else {
-						ctx.Log.Debugf("- value from %s, value: %s", v.Source(), v.String())
-					}
-				}
-
-				return true, nil
+			types := make(map[string]string)
+			keys, values, err := resolveValues(ctx, visit, func(p api.Property) error {
+				types[p.Name] = string(p.Type())
+				return encconf.Track(p)
 			})
 			if err != nil {
 				return err
@@ -134,14 +95,11 @@ func Export(metadata config.AppMetadata) *cli.Command {
 
 			// track encrypted config
 			if backend != nil {
-				jb, err := json.Marshal(&encconf)
+				version, err := encconf.Save(backend.Store(), time.Now())
 				if err != nil {
 					return err
 				}
-
-				if err := backend.Store().Upload(encconf.Path(), jb); err != nil {
-					return err
-				}
+				ctx.Log.Infof("config saved to backend, version %s", version)
 			}
 
 			// output
@@ -175,37 +133,7 @@ func Export(metadata config.AppMetadata) *cli.Command {
 
 					path = ctx.Replace(path)
 
-					filtered := []string{}
-					filteredValues := make(map[string]string)
-					for _, s := range keys {
-						if len(o.Exclude) > 0 && utils.StringSliceContains(o.Exclude, s) {
-							continue
-						}
-						if len(o.Include) > 0 && !utils.StringSliceContains(o.Include, s) {
-							continue
-						}
-
-						v, ok := values[s]
-						if !ok {
-							continue
-						}
-
-						switch o.Export {
-						case config.ExportTypeClearText:
-							switch v.(type) {
-							case *api.SensitiveValue:
-								continue
-							}
-						case config.ExportTypeSensitive:
-							switch v.(type) {
-							case *api.ClearTextValue:
-								continue
-							}
-						}
-
-						filtered = append(filtered, s)
-						filteredValues[s] = v.Raw()
-					}
+					filtered, filteredValues := filterValues(o, keys, values)
 
 					err := func() error {
 						out := os.Stdout
@@ -227,10 +155,32 @@ func Export(metadata config.AppMetadata) *cli.Command {
 							out.Write(w, filtered, o.Map, filteredValues)
 						case output.Tfvars:
 							ctx.Log.Infof("exporting values as tfvars (alias=%s path=%s)", o.Alias, path)
-							out.Write(w, filtered, o.Map, filteredValues)
+							out.WithTypes(types).Write(w, filtered, o.Map, filteredValues)
 						case output.Json:
 							ctx.Log.Infof("exporting values as json (alias=%s path=%s)", o.Alias, path)
+							out.WithTypes(types).Write(w, filtered, o.Map, filteredValues)
+						case output.K8sSecret:
+							md, err := k8sMetadata(ctx, out.Metadata)
+							if err != nil {
+								return err
+							}
+							out.Metadata = md
+							ctx.Log.Infof("exporting values as kubernetes secret (alias=%s path=%s name=%s namespace=%s)", o.Alias, path, md.Name, md.Namespace)
+							out.Write(w, filtered, o.Map, filteredValues)
+						case output.K8sConfigMap:
+							md, err := k8sMetadata(ctx, out.Metadata)
+							if err != nil {
+								return err
+							}
+							out.Metadata = md
+							ctx.Log.Infof("exporting values as kubernetes configmap (alias=%s path=%s name=%s namespace=%s)", o.Alias, path, md.Name, md.Namespace)
 							out.Write(w, filtered, o.Map, filteredValues)
+						case output.Template:
+							out.File = ctx.Replace(out.File)
+							ctx.Log.Infof("exporting values using template (alias=%s path=%s file=%s)", o.Alias, path, out.File)
+							if err := out.Render(w, filtered, o.Map, filteredValues); err != nil {
+								return err
+							}
 						default:
 							return fmt.Errorf("unsupported output type %s", o.Type)
 						}
@@ -251,3 +201,41 @@ func Export(metadata config.AppMetadata) *cli.Command {
 		},
 	}
 }
+
+// k8sMetadata replaces keys in the metadata of a kubernetes output, defaulting name and labels from the manifest
+func k8sMetadata(ctx config.AppContext, md output.K8sMetadata) (output.K8sMetadata, error) {
+	name := md.Name
+	if name == "" {
+		name = ctx.Manifest.Name
+	}
+
+	labels := md.Labels
+	if labels == nil {
+		labels = ctx.Manifest.Labels
+	}
+
+	res := output.K8sMetadata{
+		Name:      ctx.Replace(name),
+		Namespace: ctx.Replace(md.Namespace),
+	}
+
+	if len(labels) > 0 {
+		res.Labels = make(map[string]string)
+		for k, v := range labels {
+			res.Labels[k] = ctx.Replace(v)
+		}
+	}
+
+	if len(md.Annotations) > 0 {
+		res.Annotations = make(map[string]string)
+		for k, v := range md.Annotations {
+			res.Annotations[k] = ctx.Replace(v)
+		}
+	}
+
+	if res.Name == "" {
+		return res, fmt.Errorf("kubernetes outputs require a name, set name in the output config or the name of the manifest")
+	}
+
+	return res, nil
+}
@@ -0,0 +1,280 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/output"
+	"github.com/dotnetmentor/racoon/internal/visitor"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	importActionCreate    = "create"
+	importActionUpdate    = "update"
+	importActionUnchanged = "unchanged"
+	importActionSkip      = "skip"
+	importActionUnknown   = "unknown"
+)
+
+type importItem struct {
+	key      string
+	property api.Property
+	target   api.Value
+	value    string
+	action   string
+	reason   string
+}
+
+func Import(metadata config.AppMetadata) *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "Imports values from an existing dotenv, json or tfvars file into writable sources",
+		UsageText: "racoon import [command options] --from <path>",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "parameter",
+				Aliases: []string{"p"},
+				Usage:   "sets layer parameters",
+			},
+			&cli.StringFlag{
+				Name:     "from",
+				Usage:    "import values from the specified file",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "map keys to properties using output (dotenv, json, tfvars), defaults to the type matching the file extension",
+			},
+			&cli.StringFlag{
+				Name:    "alias",
+				Aliases: []string{"a"},
+				Usage:   "map keys to properties using output matching alias",
+			},
+			&cli.StringFlag{
+				Name:  "layer",
+				Usage: "only write to targets in the specified layer",
+			},
+			&cli.StringFlag{
+				Name:  "source",
+				Usage: "only write to targets of the specified source type",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "only show the plan, nothing is written",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "skips confirmation of the plan",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, err := newContext(c, metadata, true)
+			if err != nil {
+				return err
+			}
+
+			from := c.String("from")
+			ot := config.OutputType(c.String("output"))
+			if ot == "" {
+				ot, err = importOutputType(from)
+				if err != nil {
+					return err
+				}
+			}
+
+			o, err := runOutput(ctx.Manifest, ot, c.String("alias"))
+			if err != nil {
+				return err
+			}
+
+			values, err := importValues(ot, from)
+			if err != nil {
+				return err
+			}
+			ctx.Log.Infof("read %d value(s) from %s (output=%s alias=%s)", len(values), from, o.Type, o.Alias)
+
+			visit := visitor.New(ctx)
+
+			err = visit.Init([]string{}, []string{})
+			if err != nil {
+				return err
+			}
+
+			layerFilter := c.String("layer")
+			sourceFilter := c.String("source")
+
+			matched := make(map[string]bool)
+			plan := make([]importItem, 0)
+			err = visit.Property(func(p api.Property, err error) (bool, error) {
+				if err != nil {
+					return false, err
+				}
+
+				key, value, ok := "", "", false
+				for _, k := range importKeys(o, p.Name) {
+					if v, found := values[k]; found {
+						key, value, ok = k, v, true
+						break
+					}
+				}
+				if !ok {
+					return true, nil
+				}
+				matched[key] = true
+
+				item := importItem{
+					key:      key,
+					property: p,
+					value:    value,
+				}
+
+				targets := make([]api.Value, 0)
+				for _, v := range p.Values().Writable() {
+					if writeTargetMatches(v, layerFilter, sourceFilter) {
+						targets = append(targets, v)
+					}
+				}
+
+				switch {
+				case len(targets) == 0:
+					item.action = importActionSkip
+					item.reason = "no writable target"
+				case len(targets) > 1:
+					item.action = importActionSkip
+					item.reason = "multiple writable targets, use --layer or --source to select a single target"
+				default:
+					item.target = targets[0]
+					newVal := api.NewValue(item.target.Source(), item.target.Key(), value, nil, p.Sensitive() || item.target.Sensitive())
+					if err := p.Validate(newVal); err != nil {
+						item.action = importActionSkip
+						item.reason = err.Error()
+					} else if api.IsNotFoundError(item.target.Error()) {
+						item.action = importActionCreate
+					} else if item.target.Error() == nil && item.target.Raw() == value {
+						item.action = importActionUnchanged
+					} else {
+						item.action = importActionUpdate
+					}
+				}
+
+				plan = append(plan, item)
+				return true, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for k := range values {
+				if !matched[k] {
+					plan = append(plan, importItem{key: k, action: importActionUnknown, reason: "no matching property"})
+				}
+			}
+			sort.SliceStable(plan, func(i, j int) bool {
+				return plan[i].key < plan[j].key
+			})
+
+			writes := 0
+			for _, i := range plan {
+				line := fmt.Sprintf("%-9s %s", i.action, i.key)
+				if len(i.property.Name) > 0 {
+					line += fmt.Sprintf(" (property=%s)", i.property.Name)
+				}
+				if i.target != nil {
+					line += fmt.Sprintf(" -> %s", i.target.SourceAndKey())
+				}
+				if len(i.reason) > 0 {
+					line += fmt.Sprintf(", %s", i.reason)
+				}
+				fmt.Fprintln(c.App.Writer, line)
+
+				if i.action == importActionCreate || i.action == importActionUpdate {
+					writes++
+				}
+			}
+			fmt.Fprintf(c.App.Writer, "\n%d value(s) to write\n", writes)
+
+			if writes == 0 || c.Bool("dry-run") {
+				return nil
+			}
+
+			if !c.Bool("yes") && !promptYesNo("write values according to plan") {
+				return nil
+			}
+
+			for _, i := range plan {
+				if i.action != importActionCreate && i.action != importActionUpdate {
+					continue
+				}
+				ctx.Log.Debugf("importing %s into %s", i.key, i.target.SourceAndKey())
+				if err := visit.Store().Write(i.target.Key(), i.value, i.property.Description, i.target.Source().Type(), i.target.Source().Layer().Config); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func importOutputType(path string) (config.OutputType, error) {
+	base := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(base, ".json"):
+		return config.OutputTypeJson, nil
+	case strings.HasSuffix(base, ".tfvars"):
+		return config.OutputTypeTfvars, nil
+	case strings.HasSuffix(base, ".env") || strings.HasPrefix(base, ".env"):
+		return config.OutputTypeDotenv, nil
+	default:
+		return "", fmt.Errorf("unable to determine the format of %s, use the --output flag", path)
+	}
+}
+
+func importValues(ot config.OutputType, path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file for reading, %v", err)
+	}
+	defer file.Close()
+
+	switch ot {
+	case config.OutputTypeDotenv:
+		return output.ParseDotenv(file)
+	case config.OutputTypeTfvars:
+		return output.ParseTfvars(file)
+	case config.OutputTypeJson:
+		return output.ParseJson(file)
+	default:
+		return nil, fmt.Errorf("unsupported output type %s, import supports %s, %s and %s", ot, config.OutputTypeDotenv, config.OutputTypeJson, config.OutputTypeTfvars)
+	}
+}
+
+// importKeys returns the keys a property is written as by an output, reversing the formatting of keys
+func importKeys(o config.OutputConfig, name string) []string {
+	kf, ok := config.AsOutput(o).(output.KeyFormatter)
+	if !ok {
+		// Structured json is flattened when read, leaving remapped or property names
+		if remapped, ok := o.Map[name]; ok && remapped != "" {
+			return []string{remapped}
+		}
+		return []string{name}
+	}
+
+	key := kf.FormatKey(name, o.Map)
+	keys := []string{key}
+
+	// Prefixes like "export " are dropped when parsing dotenv files
+	if fields := strings.Fields(key); len(fields) > 1 {
+		keys = append(keys, fields[len(fields)-1])
+	}
+	return keys
+}
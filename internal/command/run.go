@@ -0,0 +1,158 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/output"
+	"github.com/dotnetmentor/racoon/internal/visitor"
+
+	"github.com/urfave/cli/v2"
+)
+
+// MetadataExitCode is the app metadata key used by commands to set the exit code of racoon
+const MetadataExitCode string = "exitcode"
+
+func Run(metadata config.AppMetadata) *cli.Command {
+	return &cli.Command{
+		Name:      "run",
+		Usage:     "Runs a command with property values set as environment variables",
+		UsageText: "racoon run [command options] -- <command> [arguments...]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "parameter",
+				Aliases: []string{"p"},
+				Usage:   "sets layer parameters",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "format environment variable names using output",
+				Value:   string(config.OutputTypeDotenv),
+			},
+			&cli.StringFlag{
+				Name:    "alias",
+				Aliases: []string{"a"},
+				Usage:   "format environment variable names using output matching alias",
+			},
+			&cli.StringSliceFlag{
+				Name:    "include",
+				Aliases: []string{"i"},
+				Usage:   "include property in environment",
+			},
+			&cli.StringSliceFlag{
+				Name:    "exclude",
+				Aliases: []string{"e"},
+				Usage:   "exclude property from environment",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("command not specified, must be provided as arguments after --")
+			}
+
+			ctx, err := newContext(c, metadata, true)
+			if err != nil {
+				return err
+			}
+
+			ot := c.String("output")
+			oa := c.String("alias")
+
+			o, err := runOutput(ctx.Manifest, config.OutputType(ot), oa)
+			if err != nil {
+				return err
+			}
+
+			kf, ok := config.AsOutput(o).(output.KeyFormatter)
+			if !ok {
+				return fmt.Errorf("output %s does not support formatting environment variable names", o.Type)
+			}
+
+			visit := visitor.New(ctx)
+
+			err = visit.Init(c.StringSlice("exclude"), c.StringSlice("include"))
+			if err != nil {
+				return err
+			}
+
+			keys, values, err := resolveValues(ctx, visit, nil)
+			if err != nil {
+				return err
+			}
+
+			filtered, filteredValues := filterValues(o, keys, values)
+
+			// NOTE: Values are only ever passed to the child process, never written to disk
+			env := os.Environ()
+			for _, k := range filtered {
+				env = append(env, fmt.Sprintf("%s=%s", kf.FormatKey(k, o.Map), filteredValues[k]))
+			}
+
+			args := c.Args().Slice()
+			ctx.Log.Infof("running %s with %d environment variable(s) set from properties (output=%s alias=%s)", args[0], len(filtered), o.Type, o.Alias)
+
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Env = env
+			cmd.Stdin = os.Stdin
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+
+			if err := cmd.Start(); err != nil {
+				return fmt.Errorf("failed to start %s, %v", args[0], err)
+			}
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGWINCH)
+			go func() {
+				for s := range signals {
+					ctx.Log.Debugf("forwarding signal %s to %s", s, args[0])
+					cmd.Process.Signal(s)
+				}
+			}()
+
+			err = cmd.Wait()
+
+			// stop delivery before closing, signals sent to a closed channel would panic
+			signal.Stop(signals)
+			close(signals)
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitCode := exitErr.ExitCode()
+				if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+					exitCode = 128 + int(ws.Signal())
+				}
+				ctx.Log.Debugf("%s exited with code %d", args[0], exitCode)
+				c.App.Metadata[MetadataExitCode] = exitCode
+				return nil
+			}
+
+			return err
+		},
+	}
+}
+
+// runOutput finds the output used to format environment variable names, falling back to the defaults of the output type
+func runOutput(m config.Manifest, ot config.OutputType, oa string) (config.OutputConfig, error) {
+	for _, o := range m.Outputs {
+		if o.Type != ot {
+			continue
+		}
+		if oa != "" && o.Alias != oa {
+			continue
+		}
+		return o, nil
+	}
+
+	if oa != "" {
+		return config.OutputConfig{}, fmt.Errorf("unknown output (type=%s alias=%s)", ot, oa)
+	}
+
+	return config.NewOutputConfig(ot)
+}
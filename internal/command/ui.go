@@ -112,7 +112,7 @@ func configQueryHandler(ctx config.AppContext) func(w http.ResponseWriter, r *ht
 			Items: make([]httpapi.ConfigQueryItem, 0),
 		}
 
-		if files, err := backend.Store().List(); err != nil {
+		if files, err := backend.Store().List(""); err != nil {
 			response.Error = fmt.Sprintf("error listing configs: %v", err)
 			statusCode = http.StatusInternalServerError
 		} else {
@@ -125,6 +125,10 @@ func configQueryHandler(ctx config.AppContext) func(w http.ResponseWriter, r *ht
 
 			configs := make([]httpapi.ConfigQueryItem, 0)
 			for _, file := range files {
+				// versions kept as history are browsed using the backend command
+				if !api.IsSnapshotPath(file) {
+					continue
+				}
 				configs = append(configs, httpapi.ConfigQueryItem{
 					Path:      file,
 					Encrypted: true,
@@ -263,19 +267,11 @@ func decryptConfigCommandHandler(ctx config.AppContext) func(w http.ResponseWrit
 					statusCode = http.StatusInternalServerError
 				}
 
-				for i, p := range encconf.Properties {
-					if p.Sensitive && p.Value != nil && len(*p.Value) > 0 {
-						ctx.Log.Infof("decrypting property %s", p.Name)
-						ev := *p.Value
-						dv, err := backend.Encryption().Decrypt([]byte(ev))
-						if err != nil {
-							response.Error = fmt.Sprintf("error decrypting property %s: %v", p.Name, err)
-							statusCode = http.StatusInternalServerError
-							ctx.Log.Error(response.Error)
-							break
-						}
-						dsv := string(dv)
-						encconf.Properties[i].Value = &dsv
+				if response.Error == "" {
+					ctx.Log.Infof("decrypting config %s", body.Path)
+					if err := encconf.Decrypt(backend.Encryption()); err != nil {
+						response.Error = err.Error()
+						statusCode = http.StatusInternalServerError
 					}
 				}
 
@@ -1,11 +1,18 @@
 package command
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/dotnetmentor/racoon/internal/api"
 	"github.com/dotnetmentor/racoon/internal/backend"
 	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/utils"
+	"github.com/dotnetmentor/racoon/internal/visitor"
 	"github.com/sirupsen/logrus"
+	"github.com/ttacon/chalk"
 	"github.com/urfave/cli/v2"
 )
 
@@ -48,6 +55,7 @@ func newContext(c *cli.Context, metadata config.AppMetadata, validateParams bool
 
 	ctx.Parameters = p.Ordered(ctx.Manifest.Config.Parameters)
 	ctx.Context = c.Context
+	ctx.Concurrency = c.Int("concurrency")
 
 	return ctx, nil
 }
@@ -65,3 +73,101 @@ func newBackend(ctx config.AppContext) (backend.Backend, error) {
 	}
 	return nil, nil
 }
+
+// resolveValues visits all properties, validating their resolved values and optionally tracking them
+func resolveValues(ctx config.AppContext, visit *visitor.Visitor, track func(p api.Property) error) (keys []string, values map[string]api.Value, err error) {
+	keys = []string{}
+	values = map[string]api.Value{}
+
+	err = visit.Property(func(p api.Property, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+
+		if track != nil {
+			if err := track(p); err != nil {
+				return false, err
+			}
+		}
+
+		key := p.Name
+
+		if !utils.StringSliceContains(keys, key) {
+			keys = append(keys, key)
+		}
+
+		val := p.Value()
+		if err := p.Validate(val); err != nil {
+			return false, err
+		}
+
+		// If validation passes but the value is nil, continue
+		if val == nil {
+			return true, nil
+		}
+
+		// If validation passes but we have a not found error for the resolved value, skip export
+		if !api.IsNotFoundError(val.Error()) {
+			values[key] = val
+		}
+
+		ctx.Log.Infof("property %s, defined in %s, value from %s, value set to: %s", p.Name, p.Source(), val.Source(), val.String())
+		for _, v := range p.Values() {
+			if err := p.Validate(v); err != nil {
+				ctx.Log.Debugf("- value from %s is invalid, err: %v", v.Source(), err)
+			} else {
+				ctx.Log.Debugf("- value from %s, value: %s", v.Source(), v.String())
+			}
+		}
+
+		return true, nil
+	})
+	return
+}
+
+// filterValues applies the include, exclude and export filters of an output
+func filterValues(o config.OutputConfig, keys []string, values map[string]api.Value) (filtered []string, filteredValues map[string]string) {
+	filtered = []string{}
+	filteredValues = make(map[string]string)
+	for _, s := range keys {
+		if len(o.Exclude) > 0 && utils.StringSliceContains(o.Exclude, s) {
+			continue
+		}
+		if len(o.Include) > 0 && !utils.StringSliceContains(o.Include, s) {
+			continue
+		}
+
+		v, ok := values[s]
+		if !ok {
+			continue
+		}
+
+		switch o.Export {
+		case config.ExportTypeClearText:
+			switch v.(type) {
+			case *api.SensitiveValue:
+				continue
+			}
+		case config.ExportTypeSensitive:
+			switch v.(type) {
+			case *api.ClearTextValue:
+				continue
+			}
+		}
+
+		filtered = append(filtered, s)
+		filteredValues[s] = v.Raw()
+	}
+	return
+}
+
+func promptYesNo(msg string) bool {
+	fmt.Printf("%s? %s%s (yes/no): ", chalk.Green, chalk.White, msg)
+	reader := bufio.NewReader(os.Stdin)
+	value, _ := reader.ReadString('\n')
+	value = strings.TrimSuffix(value, "\n")
+	if value == "yes" || value == "y" {
+		return true
+	}
+	return false
+}
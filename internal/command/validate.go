@@ -0,0 +1,339 @@
+package command
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/visitor"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	validateFormatText  = "text"
+	validateFormatJson  = "json"
+	validateFormatJunit = "junit"
+
+	validateStatusOk         = "ok"
+	validateStatusMissing    = "missing"
+	validateStatusFormatting = "formatting"
+	validateStatusInvalid    = "invalid"
+	validateStatusError      = "error"
+)
+
+type validateReport struct {
+	Combinations []validatedCombination `json:"combinations"`
+	Failures     int                    `json:"failures"`
+}
+
+type validatedCombination struct {
+	Name       string              `json:"name"`
+	Parameters map[string]string   `json:"parameters"`
+	Layers     []string            `json:"layers"`
+	Properties []validatedProperty `json:"properties"`
+	Error      string              `json:"error,omitempty"`
+	Failures   int                 `json:"failures"`
+}
+
+type validatedProperty struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Layer   string `json:"layer,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func Validate(metadata config.AppMetadata) *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "Validates the values of properties for every combination of parameter values",
+		UsageText: "racoon validate [command options] [property...]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "parameter",
+				Aliases: []string{"p"},
+				Usage:   "sets a single value for a layer parameter",
+			},
+			&cli.StringSliceFlag{
+				Name:  "matrix",
+				Usage: "sets the values of a layer parameter (<key>=<value>[,<value>...]), replaces values declared in the manifest",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "sets the report format (text, json, junit)",
+				Value:   validateFormatText,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			switch format {
+			case validateFormatText, validateFormatJson, validateFormatJunit:
+			default:
+				return fmt.Errorf("unsupported format %s, must be one of %s, %s or %s", format, validateFormatText, validateFormatJson, validateFormatJunit)
+			}
+
+			includes := make([]string, 0)
+			for _, a := range c.Args().Slice() {
+				includes = append(includes, strings.TrimSpace(a))
+			}
+
+			ctx, err := newContext(c, metadata, false)
+			if err != nil {
+				return err
+			}
+
+			matrix, err := config.ParseMatrix(c.StringSlice("matrix"))
+			if err != nil {
+				return err
+			}
+			// parameters are read from the flag as the context leaves out parameters not defined by the manifest,
+			// undefined parameters are rejected when building the matrix
+			params, err := config.ParseParams(c.StringSlice("parameter"))
+			if err != nil {
+				return err
+			}
+			for k, v := range params {
+				matrix[k] = []string{v}
+			}
+
+			combinations, err := config.Matrix(ctx.Manifest.Config.Parameters, matrix)
+			if err != nil {
+				return err
+			}
+			ctx.Log.Infof("validating %d combination(s) of parameter values", len(combinations))
+
+			report := validateReport{
+				Combinations: make([]validatedCombination, 0),
+			}
+			for _, params := range combinations {
+				cctx := ctx
+				cctx.Parameters = params.Ordered(ctx.Manifest.Config.Parameters)
+
+				vc := validateCombination(cctx, params.ValidateParams(ctx.Manifest.Config.Parameters), includes)
+				report.Failures += vc.Failures
+				report.Combinations = append(report.Combinations, vc)
+			}
+
+			switch format {
+			case validateFormatJson:
+				enc := json.NewEncoder(c.App.Writer)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(report)
+			case validateFormatJunit:
+				err = writeJunitReport(c.App.Writer, report)
+			default:
+				writeValidateReport(c.App.Writer, report)
+			}
+			if err != nil {
+				return err
+			}
+
+			if report.Failures > 0 {
+				return fmt.Errorf("validation failed, %d problem(s) found", report.Failures)
+			}
+			return nil
+		},
+	}
+}
+
+// validateCombination validates all properties using the parameters of the context
+func validateCombination(ctx config.AppContext, paramErr error, includes []string) validatedCombination {
+	vc := validatedCombination{
+		Name:       ctx.Parameters.String(),
+		Parameters: make(map[string]string),
+		Layers:     make([]string, 0),
+		Properties: make([]validatedProperty, 0),
+	}
+	if len(vc.Name) == 0 {
+		vc.Name = "default"
+	}
+	for _, p := range ctx.Parameters {
+		vc.Parameters[p.Key] = p.Value
+	}
+
+	fail := func(err error) validatedCombination {
+		vc.Error = err.Error()
+		vc.Failures++
+		return vc
+	}
+
+	if paramErr != nil {
+		return fail(paramErr)
+	}
+
+	visit := visitor.New(ctx)
+	if err := visit.Init([]string{}, includes); err != nil {
+		return fail(err)
+	}
+
+	err := visit.Layer(func(l api.Layer, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		vc.Layers = append(vc.Layers, l.Name)
+		return true, nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	err = visit.Property(func(p api.Property, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		vp := validateProperty(p)
+		if vp.Status != validateStatusOk {
+			vc.Failures++
+		}
+		vc.Properties = append(vc.Properties, vp)
+		return true, nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	return vc
+}
+
+func validateProperty(p api.Property) validatedProperty {
+	vp := validatedProperty{
+		Name:   p.Name,
+		Status: validateStatusOk,
+	}
+
+	v := p.Value()
+	if v != nil {
+		vp.Layer = v.Source().Layer().Name
+		vp.Source = string(v.Source().Type())
+	}
+
+	err := p.Validate(v)
+	if err == nil {
+		return vp
+	}
+	vp.Message = err.Error()
+	var validationErr *api.ValidationError
+	if errors.As(err, &validationErr) {
+		// reports are stored by ci systems, messages must not include values
+		vp.Message = validationErr.Message()
+	}
+
+	switch {
+	case v == nil || api.IsNotFoundError(v.Error()):
+		vp.Status = validateStatusMissing
+	case api.IsFormattingError(v.Error()):
+		vp.Status = validateStatusFormatting
+	case v.Error() != nil:
+		vp.Status = validateStatusError
+	default:
+		vp.Status = validateStatusInvalid
+	}
+	return vp
+}
+
+func writeValidateReport(w io.Writer, r validateReport) {
+	failed := 0
+	for i, vc := range r.Combinations {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if vc.Failures > 0 {
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s (layers: %s)\n", vc.Name, strings.Join(vc.Layers, " -> "))
+		if len(vc.Error) > 0 {
+			fmt.Fprintf(w, "  %-10s %s\n", validateStatusError, vc.Error)
+		}
+		for _, vp := range vc.Properties {
+			line := fmt.Sprintf("  %-10s %s", vp.Status, vp.Name)
+			if len(vp.Layer) > 0 {
+				line += fmt.Sprintf(" (%s/%s)", vp.Layer, vp.Source)
+			}
+			if len(vp.Message) > 0 {
+				line += fmt.Sprintf(", %s", vp.Message)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	fmt.Fprintf(w, "\n%d of %d combination(s) failed, %d problem(s) found\n", failed, len(r.Combinations), r.Failures)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJunitReport(w io.Writer, r validateReport) error {
+	suites := junitTestSuites{
+		Name:     "racoon validate",
+		Failures: r.Failures,
+	}
+
+	for _, vc := range r.Combinations {
+		suite := junitTestSuite{
+			Name:     vc.Name,
+			Failures: vc.Failures,
+		}
+		if len(vc.Error) > 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      "layers",
+				ClassName: vc.Name,
+				Failure:   &junitFailure{Message: vc.Error, Type: validateStatusError, Text: vc.Error},
+			})
+		}
+		for _, vp := range vc.Properties {
+			tc := junitTestCase{
+				Name:      vp.Name,
+				ClassName: vc.Name,
+			}
+			if vp.Status != validateStatusOk {
+				tc.Failure = &junitFailure{Message: fmt.Sprintf("%s value for property %s", vp.Status, vp.Name), Type: vp.Status, Text: vp.Message}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
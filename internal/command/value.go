@@ -0,0 +1,229 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/visitor"
+
+	"github.com/urfave/cli/v2"
+)
+
+func Value(metadata config.AppMetadata) *cli.Command {
+	parameterFlag := &cli.StringSliceFlag{
+		Name:    "parameter",
+		Aliases: []string{"p"},
+		Usage:   "sets layer parameters",
+	}
+	fromFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from-layer",
+			Usage: "selects the value of the property in the specified layer",
+		},
+		&cli.StringFlag{
+			Name:  "from-source",
+			Usage: "selects the value of the property from the specified source type",
+		},
+	}
+	toFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "to-layer",
+			Usage: "selects the target of the property in the specified layer",
+		},
+		&cli.StringFlag{
+			Name:  "to-source",
+			Usage: "selects the target of the property from the specified source type",
+		},
+	}
+	yesFlag := &cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "skips confirmations",
+	}
+
+	return &cli.Command{
+		Name:  "value",
+		Usage: "Manages values in writable sources",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "delete",
+				Usage:     "Deletes the value of a property from a writable source",
+				UsageText: "racoon value delete [command options] <property>",
+				Flags:     append([]cli.Flag{parameterFlag, yesFlag}, fromFlags...),
+				Action: func(c *cli.Context) error {
+					ctx, visit, p, err := valueProperty(c, metadata)
+					if err != nil {
+						return err
+					}
+
+					from, err := valueTarget(p, c.String("from-layer"), c.String("from-source"))
+					if err != nil {
+						return err
+					}
+
+					if api.IsNotFoundError(from.Error()) {
+						ctx.Log.Infof("property %s has no value in %s, nothing to delete", p.Name, from.SourceAndKey())
+						return nil
+					}
+
+					if !c.Bool("yes") && !promptYesNo(fmt.Sprintf("delete value of %s from %s", p.Name, from.SourceAndKey())) {
+						return nil
+					}
+
+					return visit.Store().Delete(from.Key(), from.Source().Type(), from.Source().Layer().Config)
+				},
+			},
+			{
+				Name:      "copy",
+				Usage:     "Copies the value of a property from one writable source to another",
+				UsageText: "racoon value copy [command options] <property>",
+				Flags:     append(append([]cli.Flag{parameterFlag, yesFlag}, fromFlags...), toFlags...),
+				Action: func(c *cli.Context) error {
+					ctx, visit, p, from, to, unchanged, err := valueFromTo(c, metadata)
+					if err != nil || unchanged {
+						return err
+					}
+
+					if !c.Bool("yes") && !promptYesNo(fmt.Sprintf("copy value of %s from %s to %s", p.Name, from.SourceAndKey(), to.SourceAndKey())) {
+						return nil
+					}
+
+					if err := visit.Store().Copy(from, to, p.Description); err != nil {
+						return err
+					}
+					ctx.Log.Infof("copied value of %s from %s to %s", p.Name, from.SourceAndKey(), to.SourceAndKey())
+					return nil
+				},
+			},
+			{
+				Name:      "move",
+				Usage:     "Moves the value of a property from one writable source to another",
+				UsageText: "racoon value move [command options] <property>",
+				Flags:     append(append([]cli.Flag{parameterFlag, yesFlag}, fromFlags...), toFlags...),
+				Action: func(c *cli.Context) error {
+					ctx, visit, p, from, to, unchanged, err := valueFromTo(c, metadata)
+					if err != nil {
+						return err
+					}
+
+					if !c.Bool("yes") && !promptYesNo(fmt.Sprintf("move value of %s from %s to %s", p.Name, from.SourceAndKey(), to.SourceAndKey())) {
+						return nil
+					}
+
+					// NOTE: The source is only deleted once the copy has been verified by reading back the target,
+					// a target already holding the value (e.g. when re-running a failed move) is verified when resolved
+					if !unchanged {
+						if err := visit.Store().Copy(from, to, p.Description); err != nil {
+							return fmt.Errorf("move aborted, %s was not deleted, %v", from.SourceAndKey(), err)
+						}
+						ctx.Log.Infof("copied value of %s from %s to %s", p.Name, from.SourceAndKey(), to.SourceAndKey())
+					}
+
+					if err := visit.Store().Delete(from.Key(), from.Source().Type(), from.Source().Layer().Config); err != nil {
+						return fmt.Errorf("value copied to %s but deleting %s failed, %v", to.SourceAndKey(), from.SourceAndKey(), err)
+					}
+					ctx.Log.Infof("moved value of %s from %s to %s", p.Name, from.SourceAndKey(), to.SourceAndKey())
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// valueProperty resolves the single property specified as argument
+func valueProperty(c *cli.Context, metadata config.AppMetadata) (config.AppContext, *visitor.Visitor, api.Property, error) {
+	if c.Args().Len() != 1 {
+		return config.AppContext{}, nil, api.Property{}, fmt.Errorf("property name not specified, must be provided as a single argument")
+	}
+	key := strings.TrimSpace(c.Args().First())
+
+	ctx, err := newContext(c, metadata, true)
+	if err != nil {
+		return ctx, nil, api.Property{}, err
+	}
+
+	visit := visitor.New(ctx)
+	if err := visit.Init([]string{}, []string{key}); err != nil {
+		return ctx, nil, api.Property{}, err
+	}
+
+	var property *api.Property
+	err = visit.Property(func(p api.Property, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		if p.Name == key {
+			property = &p
+		}
+		return true, nil
+	})
+	if err != nil {
+		return ctx, nil, api.Property{}, err
+	}
+	if property == nil {
+		return ctx, nil, api.Property{}, fmt.Errorf("property %s not found", key)
+	}
+
+	return ctx, visit, *property, nil
+}
+
+// valueFromTo resolves the source and target values of a copy or move, unchanged is true when the target already
+// holds the value of the source
+func valueFromTo(c *cli.Context, metadata config.AppMetadata) (ctx config.AppContext, visit *visitor.Visitor, p api.Property, from api.Value, to api.Value, unchanged bool, err error) {
+	ctx, visit, p, err = valueProperty(c, metadata)
+	if err != nil {
+		return
+	}
+
+	from, err = valueTarget(p, c.String("from-layer"), c.String("from-source"))
+	if err != nil {
+		return
+	}
+	to, err = valueTarget(p, c.String("to-layer"), c.String("to-source"))
+	if err != nil {
+		return
+	}
+
+	if from.Source().Type() == to.Source().Type() && from.Key() == to.Key() {
+		err = fmt.Errorf("source and target are the same, %s", from.SourceAndKey())
+		return
+	}
+	if from.Error() != nil {
+		err = fmt.Errorf("unable to read value of %s from %s, %v", p.Name, from.SourceAndKey(), from.Error())
+		return
+	}
+	if to.Error() == nil && to.Raw() == from.Raw() {
+		ctx.Log.Infof("value of %s in %s is unchanged", p.Name, to.SourceAndKey())
+		unchanged = true
+		return
+	}
+	if to.Error() == nil {
+		ctx.Log.Warnf("the existing value of %s in %s will be overwritten", p.Name, to.SourceAndKey())
+	}
+	return
+}
+
+// valueTarget selects a single writable value of a property
+func valueTarget(p api.Property, layer, source string) (api.Value, error) {
+	targets := make([]api.Value, 0)
+	for _, v := range p.Values().Writable() {
+		if writeTargetMatches(v, layer, source) {
+			targets = append(targets, v)
+		}
+	}
+
+	switch len(targets) {
+	case 0:
+		return nil, fmt.Errorf("no writable value found for property %s (layer=%s source=%s)", p.Name, layer, source)
+	case 1:
+		return targets[0], nil
+	default:
+		keys := make([]string, len(targets))
+		for i, t := range targets {
+			keys[i] = t.SourceAndKey()
+		}
+		return nil, fmt.Errorf("multiple writable values found for property %s, specify layer or source to select a single value (values=%v)", p.Name, keys)
+	}
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -26,6 +27,35 @@ func Write(metadata config.AppMetadata) *cli.Command {
 				Aliases: []string{"p"},
 				Usage:   "sets layer parameters",
 			},
+			&cli.StringFlag{
+				Name:  "layer",
+				Usage: "only write to targets in the specified layer",
+			},
+			&cli.StringFlag{
+				Name:  "source",
+				Usage: "only write to targets of the specified source type",
+			},
+			&cli.StringFlag{
+				Name:  "formatter",
+				Usage: "only write to formatter targets using the specified formatting key",
+			},
+			&cli.BoolFlag{
+				Name:  "value-from-stdin",
+				Usage: "reads the new value from stdin, enables non-interactive mode",
+			},
+			&cli.StringFlag{
+				Name:  "value-from-file",
+				Usage: "reads the new value from the specified file, enables non-interactive mode",
+			},
+			&cli.StringFlag{
+				Name:  "value-from-env",
+				Usage: "reads the new value from the specified environment variable, enables non-interactive mode",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "skips confirmations in non-interactive mode",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			ctx, err := newContext(c, metadata, true)
@@ -43,6 +73,24 @@ func Write(metadata config.AppMetadata) *cli.Command {
 				}
 			}
 
+			nonInteractive, newValue, err := writeValueFromFlags(c)
+			if err != nil {
+				return err
+			}
+
+			if nonInteractive {
+				if len(includes) != 1 {
+					return fmt.Errorf("non-interactive mode requires a single property name as argument")
+				}
+				if c.Bool("value-from-stdin") && !c.Bool("yes") {
+					return fmt.Errorf("the flag --yes is required when reading the value from stdin")
+				}
+			}
+
+			layerFilter := c.String("layer")
+			sourceFilter := c.String("source")
+			formatterFilter := c.String("formatter")
+
 			type writable struct {
 				layer        api.Layer
 				value        api.Value
@@ -55,6 +103,7 @@ func Write(metadata config.AppMetadata) *cli.Command {
 				property    string
 				description string
 				sensitive   bool
+				valueType   string
 				source      string
 				sourceKey   string
 				formatter   string
@@ -89,17 +138,6 @@ func Write(metadata config.AppMetadata) *cli.Command {
 				return value
 			}
 
-			promptYesNo := func(msg string) bool {
-				fmt.Printf("%s? %s%s (yes/no): ", chalk.Green, chalk.White, msg)
-				reader := bufio.NewReader(os.Stdin)
-				value, _ := reader.ReadString('\n')
-				value = strings.TrimSuffix(value, "\n")
-				if value == "yes" || value == "y" {
-					return true
-				}
-				return false
-			}
-
 			visit := visitor.New(ctx)
 
 			setNewValue := func(i valueInfo, p api.Property, v api.Value, sourceConfig config.SourceConfig, ctx config.AppContext) (api.Value, error) {
@@ -109,6 +147,9 @@ func Write(metadata config.AppMetadata) *cli.Command {
 				fmt.Printf(infoFmt, chalk.Magenta, chalk.Cyan, "property", chalk.White, i.property)
 				fmt.Printf(infoFmt, chalk.Magenta, chalk.Cyan, "desription", chalk.White, i.description)
 				fmt.Printf(infoFmt, chalk.Magenta, chalk.Cyan, "sensitive", chalk.White, fmt.Sprintf("%v", i.sensitive))
+				if len(i.valueType) > 0 {
+					fmt.Printf(infoFmt, chalk.Magenta, chalk.Cyan, "type", chalk.White, i.valueType)
+				}
 				fmt.Printf(infoFmt, chalk.Magenta, chalk.Cyan, "source", chalk.White, i.source)
 				fmt.Printf(infoFmt, chalk.Magenta, chalk.Cyan, "source key", chalk.White, i.sourceKey)
 				if len(i.formatter) > 0 {
@@ -191,6 +232,9 @@ func Write(metadata config.AppMetadata) *cli.Command {
 
 				wSources := make([]writable, 0)
 				for _, v := range p.Values().Writable() {
+					if len(formatterFilter) > 0 || !writeTargetMatches(v, layerFilter, sourceFilter) {
+						continue
+					}
 					wSources = append(wSources, writable{
 						layer:        v.Source().Layer(),
 						value:        v,
@@ -206,7 +250,13 @@ func Write(metadata config.AppMetadata) *cli.Command {
 					if lp := l.Property(p.Name); lp != nil {
 						for _, fc := range lp.WritableFormatters() {
 							f := api.NewFormatter(fc, ctx.Log)
+							if len(formatterFilter) > 0 && f.FormattingKey() != formatterFilter {
+								continue
+							}
 							val := visit.Store().Read(l, f.FormattingKey(), p.Sensitive() || lp.Sensitive(), f.Source(), l.Config)
+							if !writeTargetMatches(val, layerFilter, sourceFilter) {
+								continue
+							}
 							wFormatters = append(wFormatters, writable{
 								layer:        l,
 								value:        val,
@@ -220,6 +270,46 @@ func Write(metadata config.AppMetadata) *cli.Command {
 					return false, err
 				}
 
+				if nonInteractive {
+					targets := append(wSources, wFormatters...)
+					if len(targets) == 0 {
+						return false, fmt.Errorf("no writable target found for property %s (layer=%s source=%s formatter=%s)", p.Name, layerFilter, sourceFilter, formatterFilter)
+					}
+					if len(targets) > 1 {
+						prompts := make([]string, len(targets))
+						for i, t := range targets {
+							prompts[i] = t.selectPrompt
+						}
+						return false, fmt.Errorf("multiple writable targets found for property %s, use --layer, --source or --formatter to select a single target (targets=%v)", p.Name, prompts)
+					}
+
+					target := targets[0]
+					lp := &p
+					if target.formatter != nil {
+						lp = target.layer.Property(p.Name)
+					}
+					sensitive := p.Sensitive() || lp.Sensitive() || target.value.Sensitive()
+					newVal := api.NewValue(target.value.Source(), target.value.Key(), newValue, nil, sensitive)
+
+					if target.formatter == nil {
+						if err := p.Validate(newVal); err != nil {
+							return false, err
+						}
+					}
+
+					if target.value.Error() == nil && newValue == target.value.Raw() {
+						ctx.Log.Infof("skipping update of %s in %s, value unchanged", target.value.Key(), target.value.Source().Type())
+						return true, nil
+					}
+
+					if !c.Bool("yes") && !promptYesNo(fmt.Sprintf("set new value for %s in %s", p.Name, target.selectPrompt)) {
+						return true, nil
+					}
+
+					ctx.Log.Debugf("setting %s in %s, new value: %s", target.value.Key(), target.value.Source().Type(), newVal.String())
+					return true, visit.Store().Write(target.value.Key(), newValue, p.Description, target.value.Source().Type(), target.layer.Config)
+				}
+
 				ok := false
 				if len(wSources) > 0 || len(wFormatters) > 0 {
 					fmt.Println()
@@ -238,6 +328,7 @@ func Write(metadata config.AppMetadata) *cli.Command {
 							property:    p.Name,
 							description: p.Description,
 							sensitive:   p.Sensitive() || target.value.Sensitive(),
+							valueType:   string(p.Type()),
 							source:      string(target.value.Source().Type()),
 							sourceKey:   target.value.Key(),
 						}
@@ -285,6 +376,10 @@ func Write(metadata config.AppMetadata) *cli.Command {
 				return err
 			}
 
+			if nonInteractive && !propertyMatch {
+				return fmt.Errorf("property %s not found", includes[0])
+			}
+
 			if !propertyMatch {
 				nArgs := len(includes)
 				if nArgs == 1 {
@@ -302,3 +397,55 @@ func Write(metadata config.AppMetadata) *cli.Command {
 		},
 	}
 }
+
+// writeValueFromFlags reads the new value when non-interactive mode is enabled by a --value-from-* flag
+func writeValueFromFlags(c *cli.Context) (nonInteractive bool, value string, err error) {
+	set := 0
+	for _, f := range []string{"value-from-stdin", "value-from-file", "value-from-env"} {
+		if c.IsSet(f) {
+			set++
+		}
+	}
+	if set == 0 {
+		return false, "", nil
+	}
+	if set > 1 {
+		return true, "", fmt.Errorf("only one of --value-from-stdin, --value-from-file and --value-from-env may be used")
+	}
+
+	switch {
+	case c.Bool("value-from-stdin"):
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return true, "", fmt.Errorf("failed to read value from stdin, %v", err)
+		}
+		value = string(b)
+	case c.String("value-from-file") != "":
+		b, err := os.ReadFile(c.String("value-from-file"))
+		if err != nil {
+			return true, "", fmt.Errorf("failed to read value from file, %v", err)
+		}
+		value = string(b)
+	case c.String("value-from-env") != "":
+		v, ok := os.LookupEnv(c.String("value-from-env"))
+		if !ok {
+			return true, "", fmt.Errorf("environment variable %s is not set", c.String("value-from-env"))
+		}
+		value = v
+	default:
+		return false, "", nil
+	}
+
+	// Same as when prompting, a trailing newline is not part of the value
+	return true, strings.TrimSuffix(value, "\n"), nil
+}
+
+func writeTargetMatches(v api.Value, layer, source string) bool {
+	if len(layer) > 0 && v.Source().Layer().Name != layer {
+		return false
+	}
+	if len(source) > 0 && string(v.Source().Type()) != source {
+		return false
+	}
+	return true
+}
@@ -16,11 +16,12 @@ var (
 )
 
 type AppContext struct {
-	Context    context.Context
-	Log        *logrus.Logger
-	Metadata   AppMetadata
-	Manifest   Manifest
-	Parameters OrderedParameterList
+	Context     context.Context
+	Log         *logrus.Logger
+	Metadata    AppMetadata
+	Manifest    Manifest
+	Parameters  OrderedParameterList
+	Concurrency int
 }
 
 type AppMetadata struct {
@@ -32,6 +32,24 @@ func UnmarshalConfig(t OutputType, config map[string]interface{}) (output.Output
 			return nil, err
 		}
 		return out, nil
+	case OutputTypeK8sSecret:
+		out := output.NewK8sSecret()
+		if err := yaml.Unmarshal(b, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case OutputTypeK8sConfigMap:
+		out := output.NewK8sConfigMap()
+		if err := yaml.Unmarshal(b, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case OutputTypeTemplate:
+		out := output.NewTemplate()
+		if err := yaml.Unmarshal(b, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
 	default:
 		panic(fmt.Errorf("unsupported output type %s", t))
 	}
@@ -45,6 +63,12 @@ func AsOutput(o OutputConfig) output.Output {
 		return o.output.(output.Tfvars)
 	case OutputTypeJson:
 		return o.output.(output.Json)
+	case OutputTypeK8sSecret:
+		return o.output.(output.K8sSecret)
+	case OutputTypeK8sConfigMap:
+		return o.output.(output.K8sConfigMap)
+	case OutputTypeTemplate:
+		return o.output.(output.Template)
 	default:
 		panic(fmt.Errorf("unsupported output type %s", o.Type))
 	}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/utils"
+)
+
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+
+	LintRuleInvalidMatch          = "invalid-match"
+	LintRuleUnreachableLayer      = "unreachable-layer"
+	LintRuleDuplicateImplicit     = "duplicate-implicit-source"
+	LintRuleLayerOnlyProperty     = "layer-only-property"
+	LintRuleBlockedOverride       = "blocked-override"
+	LintRuleOverrideNotAllowed    = "override-not-allowed"
+	LintRuleIgnoredDefault        = "ignored-default"
+	LintRuleUnusedFormatter       = "unused-formatter"
+	LintRuleUnsatisfiableMust     = "unsatisfiable-must"
+	LintRuleUnknownOutputProperty = "unknown-output-property"
+)
+
+type LintSeverity string
+
+type LintIssue struct {
+	Severity LintSeverity `json:"severity"`
+	Rule     string       `json:"rule"`
+	Path     string       `json:"path"`
+	Message  string       `json:"message"`
+}
+
+// Lint statically inspects the manifest without reading from any source, issues are returned in manifest order
+func Lint(m Manifest) (issues []LintIssue) {
+	issues = make([]LintIssue, 0)
+	add := func(severity LintSeverity, rule, path, format string, a ...interface{}) {
+		issues = append(issues, LintIssue{
+			Severity: severity,
+			Rule:     rule,
+			Path:     path,
+			Message:  fmt.Sprintf(format, a...),
+		})
+	}
+
+	// properties are defined by their first definition, base first and then layers in order. The position of the
+	// first definition is kept as later layers may repeat it exactly.
+	type position struct {
+		layer, index int
+	}
+	defined := make(map[string]PropertyConfig)
+	firstAt := make(map[string]position)
+	collect := func(layer int, pl PropertyList) {
+		for i, p := range pl {
+			if _, ok := defined[p.Name]; !ok {
+				defined[p.Name] = p
+				firstAt[p.Name] = position{layer, i}
+			}
+		}
+	}
+	collect(-1, m.Properties)
+	for li, l := range m.Layers {
+		collect(li, l.Properties)
+	}
+
+	for _, p := range m.Properties {
+		lintFormatting(add, fmt.Sprintf("properties[%s]", p.Name), p, p.Source == nil)
+	}
+
+	for li, l := range m.Layers {
+		lpath := fmt.Sprintf("layers[%s]", l.Name)
+
+		for _, expr := range l.Match {
+			k, matcher, err := ParseExpression(expr)
+			if err != nil {
+				add(LintSeverityError, LintRuleInvalidMatch, lpath, "invalid match expression %q, %v", expr, err)
+				continue
+			}
+
+			var pc *ParameterConfig
+			for i := range m.Config.Parameters {
+				if m.Config.Parameters[i].Key == k {
+					pc = &m.Config.Parameters[i]
+				}
+			}
+			if pc == nil {
+				add(LintSeverityError, LintRuleUnreachableLayer, lpath, "match expression %q references undeclared parameter %s, the layer never matches", expr, k)
+				continue
+			}
+
+			if len(pc.Values) > 0 {
+				reachable := false
+				for _, v := range pc.Values {
+					if matcher.Match(v) {
+						reachable = true
+						break
+					}
+				}
+				if !reachable {
+					add(LintSeverityWarning, LintRuleUnreachableLayer, lpath, "match expression %q matches none of the declared values of parameter %s %v", expr, k, pc.Values)
+				}
+			}
+		}
+
+		implicit := make(map[SourceType]bool)
+		for _, s := range l.ImplicitSources {
+			if implicit[s] {
+				add(LintSeverityError, LintRuleDuplicateImplicit, lpath, "implicit source %s defined multiple times", s)
+			}
+			implicit[s] = true
+		}
+
+		for pi, p := range l.Properties {
+			ppath := fmt.Sprintf("%s.properties[%s]", lpath, p.Name)
+			first := defined[p.Name]
+			isFirst := firstAt[p.Name] == position{li, pi}
+
+			inBase := utils.SliceContains(m.Properties, func(bp PropertyConfig) bool {
+				return bp.Name == p.Name
+			})
+			if !inBase {
+				add(LintSeverityWarning, LintRuleLayerOnlyProperty, ppath, "property %s is defined in layer %s but never in base", p.Name, l.Name)
+			} else if !first.Rules.Override.AllowExplicit {
+				add(LintSeverityWarning, LintRuleBlockedOverride, ppath, "property %s is skipped, explicit overrides are not allowed by property rules", p.Name)
+			}
+
+			// mirrors the overrides ignored when creating properties
+			if len(p.Description) > 0 && p.Description != first.Description {
+				add(LintSeverityWarning, LintRuleOverrideNotAllowed, ppath, "overriding description of property %s is not allowed, description is ignored", p.Name)
+			}
+			if len(p.Type) > 0 && p.Type != first.Type {
+				add(LintSeverityWarning, LintRuleOverrideNotAllowed, ppath, "overriding type of property %s is not allowed, type is ignored", p.Name)
+			}
+			if !reflect.DeepEqual(p.Rules, DefaultPropertyRules) && !reflect.DeepEqual(p.Rules, first.Rules) {
+				add(LintSeverityWarning, LintRuleOverrideNotAllowed, ppath, "overriding rules of property %s is not allowed, rules are ignored", p.Name)
+			}
+
+			if p.Default != nil && !isFirst {
+				add(LintSeverityWarning, LintRuleIgnoredDefault, ppath, "default of property %s is ignored, defaults are only used where a property is first defined", p.Name)
+			}
+
+			// formatters are applied to the value of the layer, defaults are ignored when overriding
+			if len(p.Format) > 0 && !isFirst && p.Source == nil {
+				add(LintSeverityWarning, LintRuleUnusedFormatter, ppath, "formatters of property %s are never applied, the layer defines no value to format", p.Name)
+			}
+
+			lintFormatting(add, ppath, p, isFirst && p.Source == nil)
+		}
+	}
+
+	for i, o := range m.Outputs {
+		opath := fmt.Sprintf("outputs[%d]", i)
+		if len(o.Alias) > 0 {
+			opath = fmt.Sprintf("outputs[%s]", o.Alias)
+		}
+
+		check := func(field, name string) {
+			if _, ok := defined[name]; !ok {
+				add(LintSeverityWarning, LintRuleUnknownOutputProperty, opath, "%s references unknown property %s", field, name)
+			}
+		}
+		for _, name := range o.Include {
+			check("include", name)
+		}
+		for _, name := range o.Exclude {
+			check("exclude", name)
+		}
+		mapped := make([]string, 0, len(o.Map))
+		for name := range o.Map {
+			mapped = append(mapped, name)
+		}
+		sort.Strings(mapped)
+		for _, name := range mapped {
+			check("map", name)
+		}
+	}
+
+	return issues
+}
+
+// lintFormatting checks formatters and must rules of a property, values read from sources are unknown and only
+// defaults are inspected for formatter keys
+func lintFormatting(add func(severity LintSeverity, rule, path, format string, a ...interface{}), path string, p PropertyConfig, inspectDefault bool) {
+	replaced := make([]string, 0)
+	for _, fc := range p.Format {
+		if fc.Replace == nil {
+			continue
+		}
+		replaced = append(replaced, *fc.Replace)
+
+		if inspectDefault && (p.Default == nil || !strings.Contains(*p.Default, fmt.Sprintf("{%s}", *fc.Replace))) {
+			add(LintSeverityWarning, LintRuleUnusedFormatter, path, "formatter key {%s} never appears in the default value of property %s", *fc.Replace, p.Name)
+		}
+	}
+
+	for _, must := range p.Rules.Formatting.Must {
+		switch {
+		case must.Replace == nil:
+			add(LintSeverityError, LintRuleUnsatisfiableMust, path, "must rule of property %s does not specify what to replace", p.Name)
+		case len(p.Format) == 0:
+			add(LintSeverityWarning, LintRuleUnsatisfiableMust, path, "must rule {%s} of property %s is never evaluated, no formatters defined", *must.Replace, p.Name)
+		case !utils.StringSliceContains(replaced, *must.Replace):
+			add(LintSeverityError, LintRuleUnsatisfiableMust, path, "must rule {%s} of property %s can not be satisfied, no formatter replaces it", *must.Replace, p.Name)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package config_test
+
+import (
+	"github.com/dotnetmentor/racoon/internal/config"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lint", func() {
+	lint := func(manifest string) []config.LintIssue {
+		m := config.Manifest{}
+		Expect(yaml.UnmarshalStrict([]byte(manifest), &m)).To(Succeed())
+		return config.Lint(m)
+	}
+
+	rules := func(issues []config.LintIssue) []string {
+		r := make([]string, 0)
+		for _, i := range issues {
+			r = append(r, i.Rule)
+		}
+		return r
+	}
+
+	It("returns no issues for a valid manifest", func() {
+		issues := lint(`
+name: valid
+config:
+  parameters:
+    - key: context
+      values: [dev, prod]
+properties:
+  - name: Url
+    description: url
+    default: "https://{host}"
+    format:
+      - replace: host
+        source:
+          env: {key: HOST}
+    rules:
+      formatting:
+        must:
+          - replace: host
+layers:
+  - name: prod
+    match: ["context = prod"]
+    implicitSources: [env]
+    properties:
+      - name: Url
+        source:
+          env: {key: PROD_URL}
+outputs:
+  - type: json
+    include: [Url]
+`)
+		Expect(issues).To(BeEmpty())
+	})
+
+	It("reports unreachable layers", func() {
+		issues := lint(`
+name: unreachable
+config:
+  parameters:
+    - key: context
+      values: [dev, prod]
+layers:
+  - name: test
+    match: ["context = test"]
+  - name: region
+    match: ["region = eu"]
+  - name: invalid
+    match: ["context"]
+`)
+		Expect(rules(issues)).To(Equal([]string{config.LintRuleUnreachableLayer, config.LintRuleUnreachableLayer, config.LintRuleInvalidMatch}))
+		Expect(issues[0].Severity).To(Equal(config.LintSeverityWarning))
+		Expect(issues[0].Path).To(Equal("layers[test]"))
+		Expect(issues[1].Severity).To(Equal(config.LintSeverityError))
+	})
+
+	It("reports duplicate implicit sources", func() {
+		issues := lint(`
+name: implicit
+layers:
+  - name: env
+    implicitSources: [env, env]
+`)
+		Expect(rules(issues)).To(Equal([]string{config.LintRuleDuplicateImplicit}))
+	})
+
+	It("reports property overrides", func() {
+		issues := lint(`
+name: overrides
+properties:
+  - name: Port
+    description: port
+    type: int
+    default: "80"
+    rules:
+      override:
+        allowExplicit: false
+layers:
+  - name: dev
+    properties:
+      - name: Port
+        description: other
+        type: string
+        default: "8080"
+      - name: Debug
+        default: "true"
+`)
+		Expect(rules(issues)).To(Equal([]string{
+			config.LintRuleBlockedOverride,
+			config.LintRuleOverrideNotAllowed,
+			config.LintRuleOverrideNotAllowed,
+			config.LintRuleIgnoredDefault,
+			config.LintRuleLayerOnlyProperty,
+		}))
+		Expect(issues[0].Path).To(Equal("layers[dev].properties[Port]"))
+	})
+
+	It("reports overrides repeating the first definition", func() {
+		issues := lint(`
+name: repeated
+properties:
+  - name: Url
+    description: url
+    default: "https://{host}"
+    format:
+      - replace: host
+        source:
+          literal: localhost
+layers:
+  - name: dev
+    properties:
+      - name: Url
+        description: url
+        default: "https://{host}"
+        format:
+          - replace: host
+            source:
+              literal: localhost
+`)
+		Expect(rules(issues)).To(Equal([]string{
+			config.LintRuleIgnoredDefault,
+			config.LintRuleUnusedFormatter,
+		}))
+		Expect(issues[0].Path).To(Equal("layers[dev].properties[Url]"))
+	})
+
+	It("reports formatting issues", func() {
+		issues := lint(`
+name: formatting
+properties:
+  - name: Url
+    description: url
+    default: "https://example.com"
+    format:
+      - replace: host
+        source:
+          env: {key: HOST}
+    rules:
+      formatting:
+        must:
+          - replace: port
+  - name: Path
+    description: path
+    source:
+      env: {key: URL_PATH}
+    format:
+      - replace: version
+        source:
+          literal: v1
+  - name: Name
+    description: name
+    default: "{name}"
+    rules:
+      formatting:
+        must:
+          - replace: name
+layers:
+  - name: dev
+    properties:
+      - name: Name
+        format:
+          - replace: name
+            source:
+              literal: dev
+`)
+		Expect(rules(issues)).To(Equal([]string{
+			config.LintRuleUnusedFormatter,
+			config.LintRuleUnsatisfiableMust,
+			config.LintRuleUnsatisfiableMust,
+			config.LintRuleUnusedFormatter,
+		}))
+		Expect(issues[1].Severity).To(Equal(config.LintSeverityError))
+		Expect(issues[2].Severity).To(Equal(config.LintSeverityWarning))
+	})
+
+	It("reports outputs referencing unknown properties", func() {
+		issues := lint(`
+name: outputs
+properties:
+  - name: Port
+    description: port
+    default: "80"
+outputs:
+  - type: dotenv
+    alias: env
+    include: [Port, Host]
+    exclude: [Debug]
+    map:
+      Url: URL
+`)
+		Expect(rules(issues)).To(Equal([]string{
+			config.LintRuleUnknownOutputProperty,
+			config.LintRuleUnknownOutputProperty,
+			config.LintRuleUnknownOutputProperty,
+		}))
+		Expect(issues[0].Path).To(Equal("outputs[env]"))
+	})
+})
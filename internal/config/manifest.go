@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/dotnetmentor/racoon/internal/backend"
 	"github.com/dotnetmentor/racoon/internal/output"
@@ -15,13 +16,31 @@ import (
 const (
 	SourceTypeNotSet            SourceType = "unknown"
 	SourceTypeAwsParameterStore SourceType = "awsParameterStore"
+	SourceTypeAwsSecretsManager SourceType = "awsSecretsManager"
+	SourceTypeEncryptedFile     SourceType = "encryptedFile"
 	SourceTypeEnvironment       SourceType = "env"
+	SourceTypeExec              SourceType = "exec"
+	SourceTypeFile              SourceType = "file"
 	SourceTypeLiteral           SourceType = "literal"
 	SourceTypeParameter         SourceType = "parameter"
-
-	OutputTypeDotenv OutputType = "dotenv"
-	OutputTypeTfvars OutputType = "tfvars"
-	OutputTypeJson   OutputType = "json"
+	SourceTypePlugin            SourceType = "plugin"
+	SourceTypeSops              SourceType = "sops"
+	SourceTypeVault             SourceType = "vault"
+
+	OutputTypeDotenv       OutputType = "dotenv"
+	OutputTypeTfvars       OutputType = "tfvars"
+	OutputTypeJson         OutputType = "json"
+	OutputTypeK8sSecret    OutputType = "k8sSecret"
+	OutputTypeK8sConfigMap OutputType = "k8sConfigMap"
+	OutputTypeTemplate     OutputType = "template"
+
+	PropertyTypeString   PropertyType = "string"
+	PropertyTypeInt      PropertyType = "int"
+	PropertyTypeFloat    PropertyType = "float"
+	PropertyTypeBool     PropertyType = "bool"
+	PropertyTypeUrl      PropertyType = "url"
+	PropertyTypeDuration PropertyType = "duration"
+	PropertyTypeJson     PropertyType = "json"
 
 	ExportTypeAll       ExportType = "all"
 	ExportTypeSensitive ExportType = "sensitive"
@@ -49,6 +68,18 @@ type OutputType string
 
 type ExportType string
 
+type PropertyType string
+
+// Valid returns true for known property types, not setting a type is the same as string
+func (t PropertyType) Valid() bool {
+	switch t {
+	case "", PropertyTypeString, PropertyTypeInt, PropertyTypeFloat, PropertyTypeBool, PropertyTypeUrl, PropertyTypeDuration, PropertyTypeJson:
+		return true
+	default:
+		return false
+	}
+}
+
 func NewManifest(paths []string) (Manifest, error) {
 	// base path
 	basepath, _ := os.Getwd()
@@ -236,14 +267,31 @@ func (p ParameterConfigList) HasKey(k string) bool {
 }
 
 type ParameterConfig struct {
-	Key      string `yaml:"key"`
-	Required bool   `yaml:"required"`
-	Regexp   string `yaml:"regexp,omitempty"`
+	Key      string   `yaml:"key"`
+	Required bool     `yaml:"required"`
+	Regexp   string   `yaml:"regexp,omitempty"`
+	Values   []string `yaml:"values,omitempty"`
 }
 
 type SourceConfig struct {
 	AwsParameterStore AwsParameterStoreConfig `yaml:"awsParameterStore"`
+	AwsSecretsManager AwsSecretsManagerConfig `yaml:"awsSecretsManager"`
 	Env               EnvConfig               `yaml:"env"`
+	Vault             VaultConfig             `yaml:"vault"`
+	EncryptedFile     EncryptedFileConfig     `yaml:"encryptedFile"`
+	Sops              SopsConfig              `yaml:"sops"`
+	File              FileConfig              `yaml:"file"`
+	Exec              ExecConfig              `yaml:"exec"`
+	Plugins           map[string]PluginConfig `yaml:"plugins,omitempty"`
+}
+
+// Plugin returns the merged configuration of a plugin, and false when the plugin is not declared
+func (c SourceConfig) Plugin(name string, config SourceConfig) (PluginConfig, bool) {
+	pc, ok := c.Plugins[name]
+	if lpc, lok := config.Plugins[name]; lok {
+		return pc.Merge(lpc), ok || lok
+	}
+	return pc, ok
 }
 
 type AwsParameterStoreConfig struct {
@@ -280,6 +328,251 @@ func (c AwsParameterStoreConfig) Merge(config AwsParameterStoreConfig) AwsParame
 	return nc
 }
 
+type AwsSecretsManagerConfig struct {
+	DefaultKey           string `yaml:"defaultKey"`
+	KmsKey               string `yaml:"kmsKey"`
+	ForceSensitive       bool   `yaml:"forceSensitive"`
+	TreatNotFoundAsError bool   `yaml:"treatNotFoundAsError"`
+}
+
+func (c AwsSecretsManagerConfig) Merge(config AwsSecretsManagerConfig) AwsSecretsManagerConfig {
+	nc := AwsSecretsManagerConfig{
+		DefaultKey:           c.DefaultKey,
+		ForceSensitive:       c.ForceSensitive,
+		KmsKey:               c.KmsKey,
+		TreatNotFoundAsError: c.TreatNotFoundAsError,
+	}
+
+	if len(config.DefaultKey) > 0 && nc.DefaultKey != config.DefaultKey {
+		nc.DefaultKey = config.DefaultKey
+	}
+
+	if config.ForceSensitive {
+		nc.ForceSensitive = true
+	}
+
+	if len(config.KmsKey) > 0 && nc.KmsKey != config.KmsKey {
+		nc.KmsKey = config.KmsKey
+	}
+
+	if config.TreatNotFoundAsError {
+		nc.TreatNotFoundAsError = true
+	}
+
+	return nc
+}
+
+type VaultConfig struct {
+	Address              string `yaml:"address"`
+	Mount                string `yaml:"mount"`
+	DefaultKey           string `yaml:"defaultKey"`
+	ForceSensitive       bool   `yaml:"forceSensitive"`
+	TreatNotFoundAsError bool   `yaml:"treatNotFoundAsError"`
+}
+
+func (c VaultConfig) Merge(config VaultConfig) VaultConfig {
+	nc := VaultConfig{
+		Address:              c.Address,
+		Mount:                c.Mount,
+		DefaultKey:           c.DefaultKey,
+		ForceSensitive:       c.ForceSensitive,
+		TreatNotFoundAsError: c.TreatNotFoundAsError,
+	}
+
+	if len(config.Address) > 0 && nc.Address != config.Address {
+		nc.Address = config.Address
+	}
+
+	if len(config.Mount) > 0 && nc.Mount != config.Mount {
+		nc.Mount = config.Mount
+	}
+
+	if len(config.DefaultKey) > 0 && nc.DefaultKey != config.DefaultKey {
+		nc.DefaultKey = config.DefaultKey
+	}
+
+	if config.ForceSensitive {
+		nc.ForceSensitive = true
+	}
+
+	if config.TreatNotFoundAsError {
+		nc.TreatNotFoundAsError = true
+	}
+
+	return nc
+}
+
+type EncryptedFileConfig struct {
+	Path                 string `yaml:"path"`
+	DefaultKey           string `yaml:"defaultKey"`
+	KeyEnv               string `yaml:"keyEnv"`
+	ForceSensitive       bool   `yaml:"forceSensitive"`
+	TreatNotFoundAsError bool   `yaml:"treatNotFoundAsError"`
+}
+
+func (c EncryptedFileConfig) Merge(config EncryptedFileConfig) EncryptedFileConfig {
+	nc := EncryptedFileConfig{
+		Path:                 c.Path,
+		DefaultKey:           c.DefaultKey,
+		KeyEnv:               c.KeyEnv,
+		ForceSensitive:       c.ForceSensitive,
+		TreatNotFoundAsError: c.TreatNotFoundAsError,
+	}
+
+	if len(config.Path) > 0 && nc.Path != config.Path {
+		nc.Path = config.Path
+	}
+
+	if len(config.DefaultKey) > 0 && nc.DefaultKey != config.DefaultKey {
+		nc.DefaultKey = config.DefaultKey
+	}
+
+	if len(config.KeyEnv) > 0 && nc.KeyEnv != config.KeyEnv {
+		nc.KeyEnv = config.KeyEnv
+	}
+
+	if config.ForceSensitive {
+		nc.ForceSensitive = true
+	}
+
+	if config.TreatNotFoundAsError {
+		nc.TreatNotFoundAsError = true
+	}
+
+	return nc
+}
+
+type SopsConfig struct {
+	Path                 string `yaml:"path"`
+	DefaultKey           string `yaml:"defaultKey"`
+	ForceSensitive       bool   `yaml:"forceSensitive"`
+	TreatNotFoundAsError bool   `yaml:"treatNotFoundAsError"`
+}
+
+func (c SopsConfig) Merge(config SopsConfig) SopsConfig {
+	nc := SopsConfig{
+		Path:                 c.Path,
+		DefaultKey:           c.DefaultKey,
+		ForceSensitive:       c.ForceSensitive,
+		TreatNotFoundAsError: c.TreatNotFoundAsError,
+	}
+
+	if len(config.Path) > 0 && nc.Path != config.Path {
+		nc.Path = config.Path
+	}
+
+	if len(config.DefaultKey) > 0 && nc.DefaultKey != config.DefaultKey {
+		nc.DefaultKey = config.DefaultKey
+	}
+
+	if config.ForceSensitive {
+		nc.ForceSensitive = true
+	}
+
+	if config.TreatNotFoundAsError {
+		nc.TreatNotFoundAsError = true
+	}
+
+	return nc
+}
+
+type FileConfig struct {
+	ForceSensitive       bool `yaml:"forceSensitive"`
+	TreatNotFoundAsError bool `yaml:"treatNotFoundAsError"`
+}
+
+func (c FileConfig) Merge(config FileConfig) FileConfig {
+	nc := FileConfig{
+		ForceSensitive:       c.ForceSensitive,
+		TreatNotFoundAsError: c.TreatNotFoundAsError,
+	}
+
+	if config.ForceSensitive {
+		nc.ForceSensitive = true
+	}
+
+	if config.TreatNotFoundAsError {
+		nc.TreatNotFoundAsError = true
+	}
+
+	return nc
+}
+
+type ExecConfig struct {
+	Timeout              string `yaml:"timeout"`
+	ForceSensitive       bool   `yaml:"forceSensitive"`
+	TreatNotFoundAsError bool   `yaml:"treatNotFoundAsError"`
+}
+
+func (c ExecConfig) Merge(config ExecConfig) ExecConfig {
+	nc := ExecConfig{
+		Timeout:              c.Timeout,
+		ForceSensitive:       c.ForceSensitive,
+		TreatNotFoundAsError: c.TreatNotFoundAsError,
+	}
+
+	if len(config.Timeout) > 0 && nc.Timeout != config.Timeout {
+		nc.Timeout = config.Timeout
+	}
+
+	if config.ForceSensitive {
+		nc.ForceSensitive = true
+	}
+
+	if config.TreatNotFoundAsError {
+		nc.TreatNotFoundAsError = true
+	}
+
+	return nc
+}
+
+type PluginConfig struct {
+	Command              string                 `yaml:"command"`
+	Args                 []string               `yaml:"args,omitempty"`
+	Timeout              string                 `yaml:"timeout"`
+	Config               map[string]interface{} `yaml:"config,omitempty"`
+	ForceSensitive       bool                   `yaml:"forceSensitive"`
+	TreatNotFoundAsError bool                   `yaml:"treatNotFoundAsError"`
+}
+
+func (c PluginConfig) Merge(config PluginConfig) PluginConfig {
+	nc := PluginConfig{
+		Command:              c.Command,
+		Args:                 c.Args,
+		Timeout:              c.Timeout,
+		Config:               make(map[string]interface{}),
+		ForceSensitive:       c.ForceSensitive,
+		TreatNotFoundAsError: c.TreatNotFoundAsError,
+	}
+
+	for k, v := range c.Config {
+		nc.Config[k] = v
+	}
+
+	if len(config.Command) > 0 && nc.Command != config.Command {
+		nc.Command = config.Command
+		nc.Args = config.Args
+	}
+
+	if len(config.Timeout) > 0 && nc.Timeout != config.Timeout {
+		nc.Timeout = config.Timeout
+	}
+
+	for k, v := range config.Config {
+		nc.Config[k] = v
+	}
+
+	if config.ForceSensitive {
+		nc.ForceSensitive = true
+	}
+
+	if config.TreatNotFoundAsError {
+		nc.TreatNotFoundAsError = true
+	}
+
+	return nc
+}
+
 type EnvConfig struct {
 	Dotfiles []string `yaml:"dotfiles"`
 }
@@ -289,6 +582,7 @@ type PropertyConfig struct {
 	Description string             `yaml:"description"`
 	Default     *string            `yaml:"default,omitempty"`
 	Sensitive   bool               `yaml:"sensitive,omitempty"`
+	Type        PropertyType       `yaml:"type,omitempty"`
 	Source      *ValueSourceConfig `yaml:"source,omitempty"`
 	Format      []FormattingConfig `yaml:"format,omitempty"`
 	Rules       RuleConfig         `yaml:"rules,omitempty"`
@@ -306,6 +600,16 @@ func (s *PropertyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 		return err
 	}
 
+	if !raw.Type.Valid() {
+		return fmt.Errorf("unsupported type %s for property %s", raw.Type, raw.Name)
+	}
+
+	if len(raw.Rules.Validation.Regexp) > 0 {
+		if _, err := regexp.Compile(raw.Rules.Validation.Regexp); err != nil {
+			return fmt.Errorf("invalid validation regexp for property %s, %v", raw.Name, err)
+		}
+	}
+
 	*s = PropertyConfig(raw)
 	return nil
 }
@@ -324,7 +628,13 @@ type RuleConfig struct {
 }
 
 type ValidationRuleConfig struct {
-	AllowEmpty bool `yaml:"allowEmpty"`
+	AllowEmpty bool     `yaml:"allowEmpty"`
+	Regexp     string   `yaml:"regexp,omitempty"`
+	MinLength  *int     `yaml:"minLength,omitempty"`
+	MaxLength  *int     `yaml:"maxLength,omitempty"`
+	Enum       []string `yaml:"enum,omitempty"`
+	Min        *float64 `yaml:"min,omitempty"`
+	Max        *float64 `yaml:"max,omitempty"`
 }
 
 type OverrideRuleConfig struct {
@@ -345,6 +655,13 @@ type ValueSourceConfig struct {
 	Literal           *string                     `yaml:"literal,omitempty"`
 	Environment       *ValueFromEnvironment       `yaml:"env,omitempty"`
 	AwsParameterStore *ValueFromAwsParameterStore `yaml:"awsParameterStore,omitempty"`
+	AwsSecretsManager *ValueFromAwsSecretsManager `yaml:"awsSecretsManager,omitempty"`
+	Vault             *ValueFromVault             `yaml:"vault,omitempty"`
+	EncryptedFile     *ValueFromEncryptedFile     `yaml:"encryptedFile,omitempty"`
+	Sops              *ValueFromSops              `yaml:"sops,omitempty"`
+	File              *ValueFromFile              `yaml:"file,omitempty"`
+	Exec              *ValueFromExec              `yaml:"exec,omitempty"`
+	Plugin            *ValueFromPlugin            `yaml:"plugin,omitempty"`
 }
 
 func (s *ValueSourceConfig) SourceType() SourceType {
@@ -364,6 +681,34 @@ func (s *ValueSourceConfig) SourceType() SourceType {
 		if s.AwsParameterStore != nil {
 			return SourceTypeAwsParameterStore
 		}
+
+		if s.AwsSecretsManager != nil {
+			return SourceTypeAwsSecretsManager
+		}
+
+		if s.Vault != nil {
+			return SourceTypeVault
+		}
+
+		if s.EncryptedFile != nil {
+			return SourceTypeEncryptedFile
+		}
+
+		if s.Sops != nil {
+			return SourceTypeSops
+		}
+
+		if s.File != nil {
+			return SourceTypeFile
+		}
+
+		if s.Exec != nil {
+			return SourceTypeExec
+		}
+
+		if s.Plugin != nil {
+			return SourceTypePlugin
+		}
 	}
 	return SourceTypeNotSet
 }
@@ -377,6 +722,50 @@ type ValueFromAwsParameterStore struct {
 	TreatNotFoundAsError *bool  `yaml:"treatNotFoundAsError"`
 }
 
+type ValueFromAwsSecretsManager struct {
+	Key                  string `yaml:"key"`
+	JsonKey              string `yaml:"jsonKey,omitempty"`
+	TreatNotFoundAsError *bool  `yaml:"treatNotFoundAsError"`
+}
+
+type ValueFromVault struct {
+	Key                  string `yaml:"key"`
+	Field                string `yaml:"field,omitempty"`
+	TreatNotFoundAsError *bool  `yaml:"treatNotFoundAsError"`
+}
+
+type ValueFromEncryptedFile struct {
+	Path                 string `yaml:"path,omitempty"`
+	Key                  string `yaml:"key"`
+	TreatNotFoundAsError *bool  `yaml:"treatNotFoundAsError"`
+}
+
+type ValueFromSops struct {
+	Path                 string `yaml:"path,omitempty"`
+	Key                  string `yaml:"key"`
+	TreatNotFoundAsError *bool  `yaml:"treatNotFoundAsError"`
+}
+
+type ValueFromFile struct {
+	Path                 string `yaml:"path"`
+	Query                string `yaml:"query,omitempty"`
+	TreatNotFoundAsError *bool  `yaml:"treatNotFoundAsError"`
+}
+
+type ValueFromExec struct {
+	Command              string   `yaml:"command"`
+	Args                 []string `yaml:"args,omitempty"`
+	Timeout              string   `yaml:"timeout,omitempty"`
+	Trim                 *bool    `yaml:"trim,omitempty"`
+	TreatNotFoundAsError *bool    `yaml:"treatNotFoundAsError"`
+}
+
+type ValueFromPlugin struct {
+	Name                 string `yaml:"name"`
+	Key                  string `yaml:"key,omitempty"`
+	TreatNotFoundAsError *bool  `yaml:"treatNotFoundAsError"`
+}
+
 type OutputList []OutputConfig
 
 type OutputConfig struct {
@@ -391,6 +780,23 @@ type OutputConfig struct {
 	output  output.Output
 }
 
+func NewOutputConfig(t OutputType) (OutputConfig, error) {
+	o := OutputConfig{
+		Type: t,
+	}
+	switch t {
+	case OutputTypeDotenv, OutputTypeTfvars, OutputTypeJson, OutputTypeK8sSecret, OutputTypeK8sConfigMap:
+	default:
+		return o, fmt.Errorf("unsupported output type %s", t)
+	}
+	output, err := UnmarshalConfig(t, map[string]interface{}{})
+	if err != nil {
+		return o, err
+	}
+	o.output = output
+	return o, nil
+}
+
 func (m *Manifest) GetLayers(ctx AppContext) (layers []LayerConfig, err error) {
 	for _, l := range m.Layers {
 		match, err := l.Matches(ctx.Parameters, ctx)
@@ -34,6 +34,67 @@ func ParseParams(ls []string) (parameters, error) {
 	return p, nil
 }
 
+// ParseMatrix parses parameter values in the format <key>=<value>[,<value>...]
+func ParseMatrix(ls []string) (map[string][]string, error) {
+	m := map[string][]string{}
+	for _, l := range ls {
+		parts := strings.SplitN(l, "=", 2)
+		if len(parts) != 2 {
+			return m, fmt.Errorf("invalid matrix format %s, value must conform to <key>=<value>[,<value>...], parts: %v", l, parts)
+		}
+		lk := parts[0]
+		if len(lk) < 1 {
+			return m, fmt.Errorf("invalid matrix %s, key must not be empty", l)
+		}
+		for _, v := range strings.Split(parts[1], ",") {
+			if !utils.StringSliceContains(m[lk], v) {
+				m[lk] = append(m[lk], v)
+			}
+		}
+	}
+	return m, nil
+}
+
+// Matrix returns every combination of parameter values, values from the matrix replace values declared by the
+// parameter and parameters without values are left unset
+func Matrix(pl ParameterConfigList, matrix map[string][]string) ([]parameters, error) {
+	for k := range matrix {
+		if ok := pl.HasKey(k); !ok {
+			return nil, fmt.Errorf("parameter %s, provided but not defined", k)
+		}
+	}
+
+	combinations := []parameters{{}}
+	for _, pc := range pl {
+		values, ok := matrix[pc.Key]
+		if !ok {
+			values = pc.Values
+		}
+
+		if len(values) == 0 {
+			if pc.Required {
+				return nil, fmt.Errorf("required parameter %s has no values, declare values for the parameter or provide them as part of the matrix", pc.Key)
+			}
+			continue
+		}
+
+		next := make([]parameters, 0, len(combinations)*len(values))
+		for _, c := range combinations {
+			for _, v := range values {
+				np := parameters{}
+				for k, cv := range c {
+					np[k] = cv
+				}
+				np[pc.Key] = v
+				next = append(next, np)
+			}
+		}
+		combinations = next
+	}
+
+	return combinations, nil
+}
+
 func (p parameters) ValidateParams(pl ParameterConfigList) error {
 	// validate parameters are defined by manifest
 	for k := range p {
@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"github.com/dotnetmentor/racoon/internal/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parameter", func() {
+	Context("ParseMatrix", func() {
+		It("parses comma separated values", func() {
+			m, err := config.ParseMatrix([]string{"context=dev,prod", "region=eu"})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(m).To(HaveKeyWithValue("context", []string{"dev", "prod"}))
+			Expect(m).To(HaveKeyWithValue("region", []string{"eu"}))
+		})
+
+		It("merges repeated keys", func() {
+			m, err := config.ParseMatrix([]string{"context=dev", "context=prod,dev"})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(m).To(HaveKeyWithValue("context", []string{"dev", "prod"}))
+		})
+
+		It("produces an error for invalid format", func() {
+			_, err := config.ParseMatrix([]string{"context"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Matrix", func() {
+		pl := config.ParameterConfigList{
+			{Key: "context", Required: true, Values: []string{"dev", "prod"}},
+			{Key: "region", Values: []string{"eu", "us"}},
+			{Key: "tenant"},
+		}
+
+		It("returns every combination of declared values", func() {
+			combinations, err := config.Matrix(pl, map[string][]string{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(combinations).To(HaveLen(4))
+
+			ordered := make([]string, 0)
+			for _, c := range combinations {
+				ordered = append(ordered, c.Ordered(pl).String())
+			}
+			Expect(ordered).To(Equal([]string{
+				"context=dev, region=eu",
+				"context=dev, region=us",
+				"context=prod, region=eu",
+				"context=prod, region=us",
+			}))
+		})
+
+		It("replaces declared values with values from the matrix", func() {
+			combinations, err := config.Matrix(pl, map[string][]string{"context": {"test"}, "tenant": {"a", "b"}})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(combinations).To(HaveLen(4))
+			Expect(combinations[0].Ordered(pl).String()).To(Equal("context=test, region=eu, tenant=a"))
+		})
+
+		It("produces an error for required parameters without values", func() {
+			_, err := config.Matrix(config.ParameterConfigList{{Key: "context", Required: true}}, map[string][]string{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("produces an error for parameters not defined", func() {
+			_, err := config.Matrix(pl, map[string][]string{"unknown": {"a"}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
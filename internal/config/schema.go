@@ -0,0 +1,236 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/utils"
+)
+
+const (
+	SchemaVersion = "http://json-schema.org/draft-07/schema#"
+)
+
+var (
+	schemaOutputTypes = []OutputType{
+		OutputTypeDotenv,
+		OutputTypeTfvars,
+		OutputTypeJson,
+		OutputTypeK8sSecret,
+		OutputTypeK8sConfigMap,
+		OutputTypeTemplate,
+	}
+
+	// schemaExamples lists known values of types also accepting other values
+	schemaExamples = map[reflect.Type][]string{
+		// source types are only used for implicit sources, where plugins are referenced by name
+		reflect.TypeOf(SourceType("")): {
+			string(SourceTypeAwsParameterStore),
+			string(SourceTypeAwsSecretsManager),
+			string(SourceTypeEncryptedFile),
+			string(SourceTypeEnvironment),
+			string(SourceTypeSops),
+			string(SourceTypeVault),
+		},
+	}
+
+	schemaEnums = map[reflect.Type][]string{
+		reflect.TypeOf(OutputType("")): func() (types []string) {
+			for _, t := range schemaOutputTypes {
+				types = append(types, string(t))
+			}
+			return
+		}(),
+		reflect.TypeOf(ExportType("")): {
+			string(ExportTypeAll),
+			string(ExportTypeSensitive),
+			string(ExportTypeClearText),
+		},
+		reflect.TypeOf(PropertyType("")): {
+			string(PropertyTypeString),
+			string(PropertyTypeInt),
+			string(PropertyTypeFloat),
+			string(PropertyTypeBool),
+			string(PropertyTypeUrl),
+			string(PropertyTypeDuration),
+			string(PropertyTypeJson),
+		},
+	}
+
+	schemaRequired = map[reflect.Type][]string{
+		reflect.TypeOf(PropertyConfig{}):  {"name"},
+		reflect.TypeOf(LayerConfig{}):     {"name"},
+		reflect.TypeOf(ParameterConfig{}): {"key"},
+		reflect.TypeOf(OutputConfig{}):    {"type"},
+	}
+
+	// schemaSingleSource lists types where exactly one field selects what is used
+	schemaSingleSource = map[reflect.Type]bool{
+		reflect.TypeOf(ValueSourceConfig{}): true,
+	}
+
+	schemaDescriptions = map[string]string{
+		"Manifest.extends":      "Path to a manifest to extend, relative to this manifest",
+		"Manifest.name":         "Name of the manifest, replaces {name} in keys",
+		"Manifest.labels":       "Labels used when tagging values and resources written by racoon",
+		"Manifest.backend":      "Backend used to store exported configurations",
+		"Manifest.config":       "Parameters and source configuration",
+		"Manifest.layers":       "Layers overriding properties when matching parameters",
+		"Manifest.properties":   "Properties defined in the base layer",
+		"Manifest.outputs":      "Outputs available to export",
+		"LayerConfig.match":     "Expressions matched against parameters (example: context = prod)",
+		"PropertyConfig.type":   "Type of the value, defaults to string",
+		"PropertyConfig.source": "Source to read the value from, a single source may be specified",
+		"PropertyConfig.format": "Formatters applied to the value, in order",
+		"OutputConfig.map":      "Remaps property names to keys",
+		"OutputConfig.config":   "Configuration of the output type",
+	}
+)
+
+// Schema returns a JSON Schema describing the manifest, generated from the yaml tags of the manifest types
+func Schema() map[string]interface{} {
+	g := schemaGenerator{
+		definitions: make(map[string]interface{}),
+	}
+
+	root := g.object(reflect.TypeOf(Manifest{}))
+	root["$schema"] = SchemaVersion
+	root["title"] = "racoon manifest"
+	root["definitions"] = g.definitions
+	return root
+}
+
+type schemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+func (g schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	if enum, ok := schemaEnums[t]; ok {
+		return map[string]interface{}{
+			"type": "string",
+			"enum": enum,
+		}
+	}
+
+	if examples, ok := schemaExamples[t]; ok {
+		return map[string]interface{}{
+			"type":     "string",
+			"examples": examples,
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Struct:
+		if _, ok := g.definitions[t.Name()]; !ok {
+			// reserve the name before generating to stop recursion
+			g.definitions[t.Name()] = nil
+			g.definitions[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{
+			"$ref": "#/definitions/" + t.Name(),
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": g.schema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schema(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (g schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	g.fields(t, t, properties)
+
+	o := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[t]; ok {
+		o["required"] = required
+	}
+	if schemaSingleSource[t] {
+		o["minProperties"] = 1
+		o["maxProperties"] = 1
+	}
+
+	// the config of an output depends on the output type
+	if t == reflect.TypeOf(OutputConfig{}) {
+		conditions := make([]interface{}, 0)
+		for _, ot := range schemaOutputTypes {
+			out, err := UnmarshalConfig(ot, map[string]interface{}{})
+			if err != nil {
+				continue
+			}
+			conditions = append(conditions, map[string]interface{}{
+				"if": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"type": map[string]interface{}{"const": string(ot)},
+					},
+				},
+				"then": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"config": g.schema(reflect.TypeOf(out)),
+					},
+				},
+			})
+		}
+		o["allOf"] = conditions
+	}
+
+	return o
+}
+
+func (g schemaGenerator) fields(owner, t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if utils.StringSliceContains(tag[1:], "inline") {
+			g.fields(owner, f.Type, properties)
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(f.Name)
+		}
+
+		s := g.schema(f.Type)
+		if desc, ok := schemaDescriptions[owner.Name()+"."+name]; ok {
+			if _, isRef := s["$ref"]; isRef {
+				// keywords next to $ref are ignored in draft-07
+				s = map[string]interface{}{
+					"description": desc,
+					"allOf":       []interface{}{s},
+				}
+			} else {
+				s["description"] = desc
+			}
+		}
+		properties[name] = s
+	}
+}
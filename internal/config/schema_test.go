@@ -0,0 +1,114 @@
+package config_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/config"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+	var schema map[string]interface{}
+
+	BeforeEach(func() {
+		b, err := json.Marshal(config.Schema())
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(json.Unmarshal(b, &schema)).To(Succeed())
+	})
+
+	It("matches the shipped schema", func() {
+		b, err := os.ReadFile("./../../schema/racoon.schema.json")
+		Expect(err).To(Not(HaveOccurred()))
+
+		shipped := map[string]interface{}{}
+		Expect(json.Unmarshal(b, &shipped)).To(Succeed())
+		Expect(shipped).To(Equal(schema), "schema/racoon.schema.json is outdated, regenerate it using: racoon config schema > schema/racoon.schema.json")
+	})
+
+	It("describes output config per output type", func() {
+		output := schema["definitions"].(map[string]interface{})["OutputConfig"].(map[string]interface{})
+		Expect(output["allOf"]).To(HaveLen(6))
+	})
+
+	It("accepts the test manifests", func() {
+		paths, err := filepath.Glob("./../../testdata/racoon*.yaml")
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(paths).ToNot(BeEmpty())
+
+		for _, path := range paths {
+			b, err := os.ReadFile(path)
+			Expect(err).To(Not(HaveOccurred()))
+
+			var doc interface{}
+			Expect(yaml.Unmarshal(b, &doc)).To(Succeed())
+			Expect(unknownKeys(schema, schema, jsonValue(doc), "")).To(BeEmpty(), path)
+		}
+	})
+
+	It("rejects unknown keys", func() {
+		var doc interface{}
+		Expect(yaml.Unmarshal([]byte("name: test\nproperties:\n  - name: Port\n    sorce: {}\n"), &doc)).To(Succeed())
+		Expect(unknownKeys(schema, schema, jsonValue(doc), "")).To(Equal([]string{".properties[0].sorce"}))
+	})
+})
+
+// unknownKeys walks a document returning the paths of keys not described by the schema
+func unknownKeys(root, s map[string]interface{}, doc interface{}, path string) (unknown []string) {
+	if ref, ok := s["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		s = root["definitions"].(map[string]interface{})[name].(map[string]interface{})
+	}
+	if all, ok := s["allOf"].([]interface{}); ok && s["type"] == nil {
+		for _, a := range all {
+			unknown = append(unknown, unknownKeys(root, a.(map[string]interface{}), doc, path)...)
+		}
+		return
+	}
+
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		properties, _ := s["properties"].(map[string]interface{})
+		for k, v := range d {
+			if ps, ok := properties[k].(map[string]interface{}); ok {
+				unknown = append(unknown, unknownKeys(root, ps, v, path+"."+k)...)
+			} else if as, ok := s["additionalProperties"].(map[string]interface{}); ok {
+				unknown = append(unknown, unknownKeys(root, as, v, path+"."+k)...)
+			} else if s["additionalProperties"] == false {
+				unknown = append(unknown, path+"."+k)
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, v := range d {
+				unknown = append(unknown, unknownKeys(root, items, v, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return
+}
+
+// jsonValue converts yaml maps to json compatible maps
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range t {
+			m[fmt.Sprint(k)] = jsonValue(v)
+		}
+		return m
+	case []interface{}:
+		for i := range t {
+			t[i] = jsonValue(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
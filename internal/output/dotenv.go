@@ -32,22 +32,24 @@ func (o Dotenv) Type() string {
 	return "dotenv"
 }
 
+func (o Dotenv) FormatKey(k string, remap map[string]string) string {
+	if remapped, ok := remap[k]; ok && remapped != "" {
+		return remapped
+	}
+	return utils.FormatKey(k, utils.Formatting{
+		Uppercase:     o.Uppercase,
+		WordSeparator: o.WordSeparator,
+		PathSeparator: o.PathSeparator,
+		Prefix:        o.Prefix,
+	})
+}
+
 func (o Dotenv) Write(w io.Writer, keys []string, remap map[string]string, values map[string]string) {
 	output := make(map[string]string)
 	outputKeys := make([]string, len(keys))
 
 	for i, k := range keys {
-		var key string
-		if remapped, ok := remap[k]; ok && remapped != "" {
-			key = remapped
-		} else {
-			key = utils.FormatKey(k, utils.Formatting{
-				Uppercase:     o.Uppercase,
-				WordSeparator: o.WordSeparator,
-				PathSeparator: o.PathSeparator,
-				Prefix:        o.Prefix,
-			})
-		}
+		key := o.FormatKey(k, remap)
 
 		value := strings.TrimSuffix(values[k], "\n")
 		format := "%s=%s\n"
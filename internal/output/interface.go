@@ -6,3 +6,8 @@ type Output interface {
 	Type() string
 	Write(w io.Writer, keys []string, remap map[string]string, values map[string]string)
 }
+
+// KeyFormatter is implemented by outputs with flat, formatted keys
+type KeyFormatter interface {
+	FormatKey(key string, remap map[string]string) string
+}
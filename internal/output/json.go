@@ -10,6 +10,7 @@ import (
 
 type Json struct {
 	Stuctured bool `yaml:"structured"`
+	types     map[string]string
 }
 
 func NewJson() Json {
@@ -22,6 +23,12 @@ func (o Json) Type() string {
 	return "json"
 }
 
+// WithTypes returns a copy of the output writing values of the specified types using native json types
+func (o Json) WithTypes(types map[string]string) Json {
+	o.types = types
+	return o
+}
+
 func (o Json) Write(w io.Writer, keys []string, remap map[string]string, values map[string]string) {
 	jo := make(Dict)
 	for _, k := range keys {
@@ -37,7 +44,7 @@ func (o Json) Write(w io.Writer, keys []string, remap map[string]string, values
 		}
 
 		value := strings.TrimSuffix(values[k], "\n")
-		set(jo, keyParts, value)
+		set(jo, keyParts, nativeValue(o.types[k], value))
 	}
 	if err := json.NewEncoder(w).Encode(jo); err != nil {
 		panic(err)
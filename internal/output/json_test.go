@@ -60,5 +60,39 @@ var _ = Describe("Json", func() {
 				Expect(result).To(MatchJSON(`{"Bar":"Foo","CamelCasedProperty":"Value","Dotnet.Structured.FormattedProperty":"Value","Foo":"Bar","Path.Based.Property":"Value"}`))
 			})
 		})
+
+		When("writing typed values", func() {
+			var result string
+
+			BeforeEach(func() {
+				typedKeys := []string{"Port", "Enabled", "Ratio", "Hosts", "Name", "Invalid"}
+				typedValues := map[string]string{
+					"Port":    "8080",
+					"Enabled": "true",
+					"Ratio":   "0.5",
+					"Hosts":   `["a","b"]`,
+					"Name":    "racoon",
+					"Invalid": "flase",
+				}
+				types := map[string]string{
+					"Port":    "int",
+					"Enabled": "bool",
+					"Ratio":   "float",
+					"Hosts":   "json",
+					"Name":    "string",
+					"Invalid": "bool",
+				}
+
+				_, stdout, _ := pio.Buffered(os.Stdin)
+				o := output.NewJson().WithTypes(types)
+				o.Write(stdout, typedKeys, map[string]string{}, typedValues)
+				b, _ := io.ReadAll(stdout)
+				result = string(b)
+			})
+
+			It("uses native json types", func() {
+				Expect(result).To(MatchJSON(`{"Port":8080,"Enabled":true,"Ratio":0.5,"Hosts":["a","b"],"Name":"racoon","Invalid":"flase"}`))
+			})
+		})
 	})
 })
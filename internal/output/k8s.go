@@ -0,0 +1,56 @@
+package output
+
+import (
+	"io"
+
+	"github.com/dotnetmentor/racoon/internal/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// K8sMetadata is the object metadata of a kubernetes resource
+type K8sMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// K8sKeys configures how property names are formatted as kubernetes data keys
+type K8sKeys struct {
+	Uppercase     bool   `yaml:"uppercase"`
+	WordSeparator string `yaml:"wordSeparator"`
+	PathSeparator string `yaml:"pathSeparator"`
+}
+
+func newK8sKeys() K8sKeys {
+	return K8sKeys{
+		Uppercase:     true,
+		WordSeparator: "_",
+		PathSeparator: "_",
+	}
+}
+
+func (o K8sKeys) FormatKey(k string, remap map[string]string) string {
+	if remapped, ok := remap[k]; ok && remapped != "" {
+		return remapped
+	}
+	return utils.FormatKey(k, utils.Formatting{
+		Uppercase:     o.Uppercase,
+		WordSeparator: o.WordSeparator,
+		PathSeparator: o.PathSeparator,
+	})
+}
+
+type k8sResource struct {
+	ApiVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   K8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data"`
+}
+
+func writeK8sResource(w io.Writer, r k8sResource) {
+	if err := yaml.NewEncoder(w).Encode(r); err != nil {
+		panic(err)
+	}
+}
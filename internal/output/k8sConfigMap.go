@@ -0,0 +1,39 @@
+package output
+
+import (
+	"io"
+	"strings"
+)
+
+type K8sConfigMap struct {
+	Metadata K8sMetadata `yaml:",inline"`
+	Keys     K8sKeys     `yaml:",inline"`
+}
+
+func NewK8sConfigMap() K8sConfigMap {
+	return K8sConfigMap{
+		Keys: newK8sKeys(),
+	}
+}
+
+func (o K8sConfigMap) Type() string {
+	return "k8sConfigMap"
+}
+
+func (o K8sConfigMap) FormatKey(k string, remap map[string]string) string {
+	return o.Keys.FormatKey(k, remap)
+}
+
+func (o K8sConfigMap) Write(w io.Writer, keys []string, remap map[string]string, values map[string]string) {
+	data := make(map[string]string)
+	for _, k := range keys {
+		data[o.FormatKey(k, remap)] = strings.TrimSuffix(values[k], "\n")
+	}
+
+	writeK8sResource(w, k8sResource{
+		ApiVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   o.Metadata,
+		Data:       data,
+	})
+}
@@ -0,0 +1,53 @@
+package output_test
+
+import (
+	"io"
+	"os"
+
+	pio "github.com/dotnetmentor/racoon/internal/io"
+	"github.com/dotnetmentor/racoon/internal/output"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("K8sConfigMap", func() {
+	Describe("Write", func() {
+		keys := []string{
+			"Foo",
+			"Path.Based.Property",
+		}
+		values := map[string]string{
+			"Foo":                 "Bar",
+			"Path.Based.Property": "Value: with yaml",
+		}
+
+		When("writing dotnet style keys", func() {
+			var result k8sResource
+
+			BeforeEach(func() {
+				_, stdout, _ := pio.Buffered(os.Stdin)
+				o := output.NewK8sConfigMap()
+				o.Metadata.Name = "my-config"
+				o.Keys.Uppercase = false
+				o.Keys.PathSeparator = "__"
+				o.Write(stdout, keys, map[string]string{}, values)
+				b, _ := io.ReadAll(stdout)
+				Expect(yaml.Unmarshal(b, &result)).To(Succeed())
+			})
+
+			It("is a configmap", func() {
+				Expect(result.ApiVersion).To(Equal("v1"))
+				Expect(result.Kind).To(Equal("ConfigMap"))
+				Expect(result.Type).To(BeEmpty())
+				Expect(result.Metadata.Name).To(Equal("my-config"))
+			})
+
+			It("has clear text data", func() {
+				Expect(result.Data).To(HaveKeyWithValue("Foo", "Bar"))
+				Expect(result.Data).To(HaveKeyWithValue("Path__Based__Property", "Value: with yaml"))
+			})
+		})
+	})
+})
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"encoding/base64"
+	"io"
+	"strings"
+)
+
+type K8sSecret struct {
+	Metadata   K8sMetadata `yaml:",inline"`
+	Keys       K8sKeys     `yaml:",inline"`
+	SecretType string      `yaml:"secretType"`
+}
+
+func NewK8sSecret() K8sSecret {
+	return K8sSecret{
+		Keys:       newK8sKeys(),
+		SecretType: "Opaque",
+	}
+}
+
+func (o K8sSecret) Type() string {
+	return "k8sSecret"
+}
+
+func (o K8sSecret) FormatKey(k string, remap map[string]string) string {
+	return o.Keys.FormatKey(k, remap)
+}
+
+func (o K8sSecret) Write(w io.Writer, keys []string, remap map[string]string, values map[string]string) {
+	data := make(map[string]string)
+	for _, k := range keys {
+		value := strings.TrimSuffix(values[k], "\n")
+		data[o.FormatKey(k, remap)] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	writeK8sResource(w, k8sResource{
+		ApiVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   o.Metadata,
+		Type:       o.SecretType,
+		Data:       data,
+	})
+}
@@ -0,0 +1,76 @@
+package output_test
+
+import (
+	"io"
+	"os"
+
+	pio "github.com/dotnetmentor/racoon/internal/io"
+	"github.com/dotnetmentor/racoon/internal/output"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type k8sResource struct {
+	ApiVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Type string            `yaml:"type"`
+	Data map[string]string `yaml:"data"`
+}
+
+var _ = Describe("K8sSecret", func() {
+	Describe("Write", func() {
+		keys := []string{
+			"Foo",
+			"CamelCasedProperty",
+			"Path.Based.Property",
+		}
+		values := map[string]string{
+			"Foo":                 "Bar",
+			"CamelCasedProperty":  "Value\n",
+			"Path.Based.Property": "Value: with yaml",
+		}
+
+		When("writing with defaults", func() {
+			var result k8sResource
+
+			BeforeEach(func() {
+				_, stdout, _ := pio.Buffered(os.Stdin)
+				o := output.NewK8sSecret()
+				o.Metadata.Name = "my-secret"
+				o.Metadata.Namespace = "apps"
+				o.Metadata.Labels = map[string]string{"team": "racoon"}
+				o.Metadata.Annotations = map[string]string{"owner": "racoon"}
+				o.Write(stdout, keys, map[string]string{"Foo": "remapped"}, values)
+				b, _ := io.ReadAll(stdout)
+				Expect(yaml.Unmarshal(b, &result)).To(Succeed())
+			})
+
+			It("is an opaque secret", func() {
+				Expect(result.ApiVersion).To(Equal("v1"))
+				Expect(result.Kind).To(Equal("Secret"))
+				Expect(result.Type).To(Equal("Opaque"))
+			})
+
+			It("has metadata", func() {
+				Expect(result.Metadata.Name).To(Equal("my-secret"))
+				Expect(result.Metadata.Namespace).To(Equal("apps"))
+				Expect(result.Metadata.Labels).To(HaveKeyWithValue("team", "racoon"))
+				Expect(result.Metadata.Annotations).To(HaveKeyWithValue("owner", "racoon"))
+			})
+
+			It("has base64 encoded data", func() {
+				Expect(result.Data).To(HaveKeyWithValue("remapped", "QmFy"))
+				Expect(result.Data).To(HaveKeyWithValue("CAMEL_CASED_PROPERTY", "VmFsdWU="))
+				Expect(result.Data).To(HaveKeyWithValue("PATH_BASED_PROPERTY", "VmFsdWU6IHdpdGggeWFtbA=="))
+			})
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// ParseDotenv reads keys and values from dotenv formatted input
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	return godotenv.Parse(r)
+}
+
+// ParseTfvars reads keys and string values from tfvars formatted input, as written by Tfvars
+func ParseTfvars(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid tfvars assignment on line %d", n)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") && len(value) > 1 {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// ParseJson reads keys and values from json formatted input, nested objects are flattened using "." as the
+// path separator and non string values are kept as json
+func ParseJson(r io.Reader) (map[string]string, error) {
+	d := make(map[string]interface{})
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", d); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, d map[string]interface{}) error {
+	for k, v := range d {
+		key := k
+		if len(prefix) > 0 {
+			key = prefix + "." + k
+		}
+
+		switch tv := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(values, key, tv); err != nil {
+				return err
+			}
+		case string:
+			values[key] = tv
+		default:
+			b, err := json.Marshal(tv)
+			if err != nil {
+				return err
+			}
+			values[key] = string(b)
+		}
+	}
+	return nil
+}
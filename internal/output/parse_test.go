@@ -0,0 +1,68 @@
+package output_test
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/output"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parse", func() {
+	keys := []string{
+		"Foo",
+		"CamelCasedProperty",
+		"Path.Based.Property",
+	}
+	values := map[string]string{
+		"Foo":                 "Bar",
+		"CamelCasedProperty":  "Value with \"quotes\"",
+		"Path.Based.Property": "Value",
+	}
+
+	It("reads what dotenv writes", func() {
+		var b bytes.Buffer
+		output.NewDotenv().Write(&b, []string{"Foo", "Path.Based.Property"}, map[string]string{}, values)
+
+		result, err := output.ParseDotenv(&b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(HaveKeyWithValue("FOO", "Bar"))
+		Expect(result).To(HaveKeyWithValue("PATH_BASED_PROPERTY", "Value"))
+	})
+
+	It("reads what tfvars writes", func() {
+		var b bytes.Buffer
+		output.NewTfvars().Write(&b, keys, map[string]string{}, values)
+
+		result, err := output.ParseTfvars(&b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(HaveKeyWithValue("foo", "Bar"))
+		Expect(result).To(HaveKeyWithValue("camel_cased_property", "Value with \"quotes\""))
+		Expect(result).To(HaveKeyWithValue("path_based_property", "Value"))
+	})
+
+	It("fails on invalid tfvars", func() {
+		_, err := output.ParseTfvars(strings.NewReader("foo \"bar\""))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reads what json writes", func() {
+		var b bytes.Buffer
+		output.NewJson().Write(&b, keys, map[string]string{"Foo": "Remapped"}, values)
+
+		result, err := output.ParseJson(&b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(HaveKeyWithValue("Remapped", "Bar"))
+		Expect(result).To(HaveKeyWithValue("Path.Based.Property", "Value"))
+	})
+
+	It("keeps non string json values as json", func() {
+		result, err := output.ParseJson(strings.NewReader(`{"Port": 8080, "Enabled": true, "Hosts": ["a", "b"]}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(HaveKeyWithValue("Port", "8080"))
+		Expect(result).To(HaveKeyWithValue("Enabled", "true"))
+		Expect(result).To(HaveKeyWithValue("Hosts", `["a","b"]`))
+	})
+})
@@ -0,0 +1,128 @@
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/dotnetmentor/racoon/internal/utils"
+)
+
+type Template struct {
+	File     string `yaml:"file"`
+	Template string `yaml:"template"`
+}
+
+func NewTemplate() Template {
+	return Template{}
+}
+
+func (o Template) Type() string {
+	return "template"
+}
+
+// TemplateData is the data available when rendering a template
+type TemplateData struct {
+	Keys       []string
+	Values     map[string]string
+	Properties []TemplateProperty
+}
+
+// TemplateProperty is a single property, Name is the remapped name of the property or Key when not remapped
+type TemplateProperty struct {
+	Key   string
+	Name  string
+	Value string
+}
+
+func (o Template) Write(w io.Writer, keys []string, remap map[string]string, values map[string]string) {
+	if err := o.Render(w, keys, remap, values); err != nil {
+		panic(err)
+	}
+}
+
+// Render parses and executes the template, returning any error instead of panicking
+func (o Template) Render(w io.Writer, keys []string, remap map[string]string, values map[string]string) error {
+	t, err := o.parse()
+	if err != nil {
+		return err
+	}
+
+	data := TemplateData{
+		Keys:       keys,
+		Values:     make(map[string]string),
+		Properties: make([]TemplateProperty, 0),
+	}
+	for _, k := range keys {
+		name := k
+		if remapped, ok := remap[k]; ok && remapped != "" {
+			name = remapped
+		}
+		value := strings.TrimSuffix(values[k], "\n")
+		data.Values[k] = value
+		data.Properties = append(data.Properties, TemplateProperty{Key: k, Name: name, Value: value})
+	}
+
+	// render to a buffer first, avoiding partially written output when rendering fails
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to render template, %v", err)
+	}
+	_, err = b.WriteTo(w)
+	return err
+}
+
+func (o Template) parse() (*template.Template, error) {
+	text := o.Template
+	switch {
+	case len(o.File) > 0 && len(o.Template) > 0:
+		return nil, fmt.Errorf("template output must specify either file or template, not both")
+	case len(o.File) > 0:
+		b, err := os.ReadFile(o.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file, %v", err)
+		}
+		text = string(b)
+	case len(o.Template) == 0:
+		return nil, fmt.Errorf("template output must specify either file or template")
+	}
+
+	t, err := template.New(o.Type()).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template, %v", err)
+	}
+	return t, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+	"base64": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// formatKey formats a property name using the specified word and path separators
+	"formatKey": func(key, wordSeparator, pathSeparator string) string {
+		return utils.FormatKey(key, utils.Formatting{
+			WordSeparator: wordSeparator,
+			PathSeparator: pathSeparator,
+		})
+	},
+	// default returns def when value is empty, following the argument order of sprig to allow {{ .Value | default "x" }}
+	"default": func(def, value string) string {
+		if len(value) == 0 {
+			return def
+		}
+		return value
+	},
+}
@@ -0,0 +1,81 @@
+package output_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	pio "github.com/dotnetmentor/racoon/internal/io"
+	"github.com/dotnetmentor/racoon/internal/output"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	Describe("Render", func() {
+		keys := []string{
+			"Foo",
+			"Path.Based.Property",
+			"Empty",
+		}
+		values := map[string]string{
+			"Foo":                 "Bar\n",
+			"Path.Based.Property": "Value",
+			"Empty":               "",
+		}
+
+		render := func(o output.Template, remap map[string]string) (string, error) {
+			_, stdout, _ := pio.Buffered(os.Stdin)
+			err := o.Render(stdout, keys, remap, values)
+			b, _ := io.ReadAll(stdout)
+			return string(b), err
+		}
+
+		When("rendering an inline template", func() {
+			It("has remapped names and values", func() {
+				o := output.NewTemplate()
+				o.Template = `{{ range .Properties }}{{ .Name }}={{ .Value | quote }};{{ end }}`
+				result, err := render(o, map[string]string{"Foo": "foo"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(`foo="Bar";Path.Based.Property="Value";Empty="";`))
+			})
+
+			It("has helper functions", func() {
+				o := output.NewTemplate()
+				o.Template = `{{ formatKey "Path.Based.Property" "-" "/" | lower }} {{ .Values.Foo | upper }} {{ .Values.Foo | base64 }} {{ .Values | json }} {{ .Values.Empty | default "none" }}`
+				result, err := render(o, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(`path/based/property BAR QmFy {"Empty":"","Foo":"Bar","Path.Based.Property":"Value"} none`))
+			})
+		})
+
+		When("rendering a template file", func() {
+			It("reads the template from file", func() {
+				file := filepath.Join(GinkgoT().TempDir(), "nginx.conf.tmpl")
+				Expect(os.WriteFile(file, []byte(`{{ range .Keys }}set ${{ . }};{{ end }}`), 0644)).To(Succeed())
+
+				o := output.NewTemplate()
+				o.File = file
+				result, err := render(o, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(`set $Foo;set $Path.Based.Property;set $Empty;`))
+			})
+		})
+
+		When("the template is invalid", func() {
+			It("fails without writing output", func() {
+				o := output.NewTemplate()
+				o.Template = `{{ .Missing.Field }}`
+				result, err := render(o, map[string]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(result).To(BeEmpty())
+			})
+
+			It("requires a template", func() {
+				_, err := render(output.NewTemplate(), map[string]string{})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package output
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -12,6 +13,7 @@ type Tfvars struct {
 	Lowercase     bool   `yaml:"lowercase"`
 	WordSeparator string `yaml:"wordSeparator"`
 	PathSeparator string `yaml:"pathSeparator"`
+	types         map[string]string
 }
 
 func NewTfvars() Tfvars {
@@ -26,20 +28,35 @@ func (o Tfvars) Type() string {
 	return "tfvars"
 }
 
+func (o Tfvars) FormatKey(k string, remap map[string]string) string {
+	if remapped, ok := remap[k]; ok && remapped != "" {
+		return remapped
+	}
+	return utils.FormatKey(k, utils.Formatting{
+		Lowercase:     o.Lowercase,
+		WordSeparator: o.WordSeparator,
+		PathSeparator: o.PathSeparator,
+	})
+}
+
+// WithTypes returns a copy of the output writing values of the specified types unquoted, using native types
+func (o Tfvars) WithTypes(types map[string]string) Tfvars {
+	o.types = types
+	return o
+}
+
 func (o Tfvars) Write(w io.Writer, keys []string, remap map[string]string, values map[string]string) {
 	for _, k := range keys {
-		var key string
-		if remapped, ok := remap[k]; ok && remapped != "" {
-			key = remapped
-		} else {
-			key = utils.FormatKey(k, utils.Formatting{
-				Lowercase:     o.Lowercase,
-				WordSeparator: o.WordSeparator,
-				PathSeparator: o.PathSeparator,
-			})
-		}
+		key := o.FormatKey(k, remap)
 
 		value := strings.TrimSuffix(values[k], "\n")
-		w.Write([]byte(fmt.Sprintf("%s = \"%s\"\n", key, value)))
+		switch nv := nativeValue(o.types[k], value).(type) {
+		case string:
+			w.Write([]byte(fmt.Sprintf("%s = \"%s\"\n", key, nv)))
+		default:
+			// json is valid hcl for numbers, booleans, lists and objects
+			b, _ := json.Marshal(nv)
+			w.Write([]byte(fmt.Sprintf("%s = %s\n", key, b)))
+		}
 	}
 }
@@ -98,5 +98,45 @@ var _ = Describe("Tfvars", func() {
 				Expect(lines[4]).To(ContainSubstring("Dotnet_Structured_FormattedProperty ="))
 			})
 		})
+
+		When("writing typed values", func() {
+			var result string
+
+			BeforeEach(func() {
+				typedKeys := []string{"Port", "Enabled", "Ratio", "Hosts", "Name", "Invalid"}
+				typedValues := map[string]string{
+					"Port":    "8080",
+					"Enabled": "true",
+					"Ratio":   "0.5",
+					"Hosts":   `["a","b"]`,
+					"Name":    "racoon",
+					"Invalid": "flase",
+				}
+				types := map[string]string{
+					"Port":    "int",
+					"Enabled": "bool",
+					"Ratio":   "float",
+					"Hosts":   "json",
+					"Name":    "string",
+					"Invalid": "bool",
+				}
+
+				_, stdout, _ := pio.Buffered(os.Stdin)
+				o := output.NewTfvars().WithTypes(types)
+				o.Write(stdout, typedKeys, map[string]string{}, typedValues)
+				b, _ := io.ReadAll(stdout)
+				result = string(b)
+			})
+
+			It("writes typed values unquoted", func() {
+				lines := strings.Split(result, "\n")
+				Expect(lines[0]).To(Equal("port = 8080"))
+				Expect(lines[1]).To(Equal("enabled = true"))
+				Expect(lines[2]).To(Equal("ratio = 0.5"))
+				Expect(lines[3]).To(Equal(`hosts = ["a","b"]`))
+				Expect(lines[4]).To(Equal(`name = "racoon"`))
+				Expect(lines[5]).To(Equal(`invalid = "flase"`))
+			})
+		})
 	})
 })
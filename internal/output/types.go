@@ -0,0 +1,38 @@
+package output
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Value types written using native types by outputs supporting them, matching property types
+const (
+	valueTypeInt   = "int"
+	valueTypeFloat = "float"
+	valueTypeBool  = "bool"
+	valueTypeJson  = "json"
+)
+
+// nativeValue converts a value to its native type, values that are not typed or fail to convert are kept as strings
+func nativeValue(t, v string) interface{} {
+	switch t {
+	case valueTypeInt:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	case valueTypeFloat:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case valueTypeBool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	case valueTypeJson:
+		var j interface{}
+		if err := json.Unmarshal([]byte(v), &j); err == nil {
+			return j
+		}
+	}
+	return v
+}
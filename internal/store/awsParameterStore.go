@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
@@ -18,28 +20,84 @@ import (
 	"github.com/dotnetmentor/racoon/internal/utils"
 )
 
+const (
+	parameterStoreBatchSize = 10
+)
+
 func newAwsParameterStore(ctx context.Context) (*AwsParameterStore, error) {
 	client, err := newParameterStoreClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAwsParameterStore(client), nil
+}
+
+// NewAwsParameterStore creates a parameter store source using the provided client
+func NewAwsParameterStore(client ParameterStoreClient) *AwsParameterStore {
 	return &AwsParameterStore{
 		client: client,
-	}, err
+		cache:  make(map[string]cachedParameter),
+	}
+}
+
+// ParameterStoreClient is the subset of the SSM client used by the parameter store source
+type ParameterStoreClient interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
+	DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
 }
 
 type AwsParameterStore struct {
-	client *ssm.Client
+	client ParameterStoreClient
+	mu     sync.RWMutex
+	cache  map[string]cachedParameter
+}
+
+// cachedParameter is a parameter read from parameter store, parameters not found keep the error returned by parameter store
+type cachedParameter struct {
+	value    string
+	found    bool
+	notFound *ssmtypes.ParameterNotFound
 }
 
 func (s *AwsParameterStore) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromAwsParameterStore, sourceConfig config.AwsParameterStoreConfig) api.Value {
-	pskf := sourceConfig.DefaultKey
-	if len(propertySource.Key) > 0 {
-		pskf = propertySource.Key
+	psk, err := parameterStoreKey(ctx, key, propertySource, sourceConfig)
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), "", "", err, sensitive || sourceConfig.ForceSensitive)
 	}
 
-	if len(pskf) == 0 {
-		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), "", "", missingKeyError(), sensitive || sourceConfig.ForceSensitive)
+	p, err := s.get(ctx, psk)
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, "", err, sensitive || sourceConfig.ForceSensitive)
+	}
+
+	if !p.found {
+		notFound := p.notFound
+		treatAsError := sourceConfig.TreatNotFoundAsError
+		if propertySource.TreatNotFoundAsError != nil {
+			treatAsError = *propertySource.TreatNotFoundAsError
+		}
+		if treatAsError {
+			ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", psk, config.SourceTypeAwsParameterStore)
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, "", fmt.Errorf("%s not found in %s, configured to be treated as an error, %s", psk, config.SourceTypeAwsParameterStore, notFound), sensitive || sourceConfig.ForceSensitive)
+		}
+		ctx.Log.Debugf("%s not found in %s", psk, config.SourceTypeAwsParameterStore)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, "", api.NewNotFoundError(notFound, psk, api.SourceTypeAwsParameterStore), sensitive || sourceConfig.ForceSensitive)
+	}
+
+	return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, p.value, nil, sensitive || sourceConfig.ForceSensitive)
+}
+
+// get returns a parameter from the cache, falling back to reading it from parameter store
+func (s *AwsParameterStore) get(ctx config.AppContext, psk string) (cachedParameter, error) {
+	if p, ok := s.cached(psk); ok {
+		ctx.Log.Debugf("reading %s from %s (cached)", psk, config.SourceTypeAwsParameterStore)
+		return p, nil
 	}
 
-	psk := awpParameterStoreKey(ctx.Replace(pskf), key)
 	ctx.Log.Debugf("reading %s from %s", psk, config.SourceTypeAwsParameterStore)
 	out, err := s.client.GetParameter(ctx.Context, &ssm.GetParameterInput{
 		Name:           &psk,
@@ -48,22 +106,148 @@ func (s *AwsParameterStore) Read(ctx config.AppContext, layer api.Layer, key str
 	if err != nil {
 		var notFound *ssmtypes.ParameterNotFound
 		if !errors.As(err, &notFound) {
-			return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, "", err, sensitive || sourceConfig.ForceSensitive)
-		} else {
-			treatAsError := sourceConfig.TreatNotFoundAsError
-			if propertySource.TreatNotFoundAsError != nil {
-				treatAsError = *propertySource.TreatNotFoundAsError
-			}
-			if treatAsError {
-				ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", psk, config.SourceTypeAwsParameterStore)
-				return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, "", fmt.Errorf("%s not found in %s, configured to be treated as an error, %s", psk, config.SourceTypeAwsParameterStore, notFound), sensitive || sourceConfig.ForceSensitive)
+			return cachedParameter{}, err
+		}
+		return s.setCached(psk, cachedParameter{found: false, notFound: notFound}), nil
+	}
+
+	return s.setCached(psk, cachedParameter{value: *out.Parameter.Value, found: true}), nil
+}
+
+func (s *AwsParameterStore) cached(psk string) (cachedParameter, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.cache[psk]
+	return p, ok
+}
+
+func (s *AwsParameterStore) setCached(psk string, p cachedParameter) cachedParameter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[psk] = p
+	return p
+}
+
+// Prefetch reads the specified keys in batches and caches the result, keys sharing a path with
+// more parameters than fits in a single batch are read using GetParametersByPath
+func (s *AwsParameterStore) Prefetch(ctx config.AppContext, keys []string) error {
+	pending := make([]string, 0)
+	byPath := make(map[string][]string)
+	paths := make([]string, 0)
+
+	for _, k := range keys {
+		if _, ok := s.cached(k); ok || utils.StringSliceContains(pending, k) {
+			continue
+		}
+		pending = append(pending, k)
+
+		// Keys with version or label selectors are not supported by GetParametersByPath
+		if !strings.HasPrefix(k, "/") || strings.Contains(k, ":") {
+			continue
+		}
+		p := path.Dir(k)
+		if _, ok := byPath[p]; !ok {
+			paths = append(paths, p)
+		}
+		byPath[p] = append(byPath[p], k)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	for _, p := range paths {
+		if len(byPath[p]) <= parameterStoreBatchSize {
+			continue
+		}
+		if err := s.prefetchPath(ctx, p, byPath[p]); err != nil {
+			ctx.Log.Debugf("failed to read parameters by path %s from %s, falling back to batched reads, %v", p, config.SourceTypeAwsParameterStore, err)
+		}
+	}
+
+	batch := make([]string, 0, parameterStoreBatchSize)
+	for _, k := range pending {
+		if _, ok := s.cached(k); ok {
+			continue
+		}
+		batch = append(batch, k)
+		if len(batch) == parameterStoreBatchSize {
+			if err := s.prefetchBatch(ctx, batch); err != nil {
+				return err
 			}
-			ctx.Log.Debugf("%s not found in %s", psk, config.SourceTypeAwsParameterStore)
-			return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, "", api.NewNotFoundError(notFound, psk, api.SourceTypeAwsParameterStore), sensitive || sourceConfig.ForceSensitive)
+			batch = make([]string, 0, parameterStoreBatchSize)
+		}
+	}
+	if len(batch) > 0 {
+		return s.prefetchBatch(ctx, batch)
+	}
+	return nil
+}
+
+func (s *AwsParameterStore) prefetchBatch(ctx config.AppContext, keys []string) error {
+	ctx.Log.Debugf("reading %d parameters from %s (keys=%v)", len(keys), config.SourceTypeAwsParameterStore, keys)
+	out, err := s.client.GetParameters(ctx.Context, &ssm.GetParametersInput{
+		Names:          keys,
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range out.Parameters {
+		if k, ok := requestedParameterKey(keys, p); ok {
+			s.setCached(k, cachedParameter{value: *p.Value, found: true})
+		}
+	}
+	for _, k := range out.InvalidParameters {
+		s.setCached(k, notFoundParameter(k))
+	}
+	return nil
+}
+
+// requestedParameterKey maps a returned parameter back to the requested key, parameters are returned by name without
+// selectors even when requested using a version or label selector or by ARN. Parameters not matching a requested key
+// are never cached, falling back to reading them one by one.
+func requestedParameterKey(keys []string, p ssmtypes.Parameter) (string, bool) {
+	selector := aws.ToString(p.Selector)
+	candidates := []string{aws.ToString(p.Name) + selector}
+	if p.ARN != nil {
+		candidates = append(candidates, *p.ARN+selector)
+	}
+	for _, c := range candidates {
+		if utils.StringSliceContains(keys, c) {
+			return c, true
 		}
-	} else {
-		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), psk, *out.Parameter.Value, err, sensitive || sourceConfig.ForceSensitive)
 	}
+	return "", false
+}
+
+func (s *AwsParameterStore) prefetchPath(ctx config.AppContext, p string, keys []string) error {
+	ctx.Log.Debugf("reading parameters by path %s from %s", p, config.SourceTypeAwsParameterStore)
+	found := make(map[string]string)
+	paginator := ssm.NewGetParametersByPathPaginator(s.client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(p),
+		Recursive:      aws.Bool(false),
+		WithDecryption: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx.Context)
+		if err != nil {
+			return err
+		}
+		for _, param := range out.Parameters {
+			found[*param.Name] = *param.Value
+		}
+	}
+
+	for _, k := range keys {
+		if v, ok := found[k]; ok {
+			s.setCached(k, cachedParameter{value: v, found: true})
+		} else {
+			s.setCached(k, notFoundParameter(k))
+		}
+	}
+	return nil
 }
 
 func (s *AwsParameterStore) Write(ctx config.AppContext, key, value, description string, sourceConfig config.AwsParameterStoreConfig) error {
@@ -87,24 +271,10 @@ func (s *AwsParameterStore) Write(ctx config.AppContext, key, value, description
 	}
 
 	tags := []ssmtypes.Tag{}
-
-	if ctx.Manifest.Name != "" {
+	for _, t := range resourceTags(ctx) {
 		tags = append(tags, ssmtypes.Tag{
-			Key:   aws.String("racoon/owner"),
-			Value: aws.String(ctx.Manifest.Name),
-		})
-	}
-
-	tags = append(tags, ssmtypes.Tag{
-		Key:   aws.String("racoon/version"),
-		Value: aws.String(ctx.Metadata.Version),
-	})
-
-	for k, v := range ctx.Manifest.Labels {
-		fv := ctx.Replace(v)
-		tags = append(tags, ssmtypes.Tag{
-			Key:   aws.String(k),
-			Value: aws.String(fv),
+			Key:   aws.String(t.key),
+			Value: aws.String(t.value),
 		})
 	}
 
@@ -117,9 +287,55 @@ func (s *AwsParameterStore) Write(ctx config.AppContext, key, value, description
 		return err
 	}
 
+	s.setCached(key, cachedParameter{value: value, found: true})
+
+	return nil
+}
+
+// ReadKey reads a parameter bypassing the cache, used to verify writes
+func (s *AwsParameterStore) ReadKey(ctx config.AppContext, key string) (string, bool, error) {
+	ctx.Log.Debugf("reading %s from %s (uncached)", key, config.SourceTypeAwsParameterStore)
+	out, err := s.client.GetParameter(ctx.Context, &ssm.GetParameterInput{
+		Name:           &key,
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		var notFound *ssmtypes.ParameterNotFound
+		if !errors.As(err, &notFound) {
+			return "", false, err
+		}
+		return "", false, nil
+	}
+	return *out.Parameter.Value, true, nil
+}
+
+func (s *AwsParameterStore) Delete(ctx config.AppContext, key string) error {
+	ctx.Log.Infof("deleting parameter %s in %s", key, api.SourceTypeAwsParameterStore)
+	if _, err := s.client.DeleteParameter(ctx.Context, &ssm.DeleteParameterInput{
+		Name: &key,
+	}); err != nil {
+		var notFound *ssmtypes.ParameterNotFound
+		if !errors.As(err, &notFound) {
+			ctx.Log.Errorf("failed to delete parameter %s in %s, %v", key, config.SourceTypeAwsParameterStore, err)
+			return err
+		}
+		ctx.Log.Debugf("parameter %s not found in %s, nothing to delete", key, config.SourceTypeAwsParameterStore)
+	}
+
+	s.setCached(key, notFoundParameter(key))
+
 	return nil
 }
 
+// notFoundParameter is cached for parameters missing from batched reads, using the error GetParameter returns
+// for missing parameters so that reads behave the same whether the parameter was prefetched or not
+func notFoundParameter(psk string) cachedParameter {
+	return cachedParameter{
+		found:    false,
+		notFound: &ssmtypes.ParameterNotFound{Message: aws.String(fmt.Sprintf("parameter %s not found", psk))},
+	}
+}
+
 func newParameterStoreClient(ctx context.Context) (*ssm.Client, error) {
 	if awsRegion := environment.StringVar("AWS_REGION", ""); awsRegion == "" {
 		return nil, fmt.Errorf("required environment variable AWS_REGION has no value set")
@@ -133,6 +349,19 @@ func newParameterStoreClient(ctx context.Context) (*ssm.Client, error) {
 	return ssm.NewFromConfig(awsConfig), nil
 }
 
+func parameterStoreKey(ctx config.AppContext, key string, propertySource config.ValueFromAwsParameterStore, sourceConfig config.AwsParameterStoreConfig) (string, error) {
+	pskf := sourceConfig.DefaultKey
+	if len(propertySource.Key) > 0 {
+		pskf = propertySource.Key
+	}
+
+	if len(pskf) == 0 {
+		return "", missingParameterStoreKeyError()
+	}
+
+	return awpParameterStoreKey(ctx.Replace(pskf), key), nil
+}
+
 func awpParameterStoreKey(format, key string) string {
 	nameKey := utils.FormatKey(key, utils.Formatting{
 		Lowercase:     true,
@@ -144,7 +373,7 @@ func awpParameterStoreKey(format, key string) string {
 }
 
 // NOTE: Really ugly hack to avoid magic strings, poor performance expected
-func missingKeyError() error {
+func missingParameterStoreKeyError() error {
 	m := config.Manifest{}
 	p := config.PropertyConfig{
 		Source: &config.ValueSourceConfig{
@@ -153,7 +382,7 @@ func missingKeyError() error {
 	}
 	configKey := strings.Join(tagsForFields(&m, &m.Config, &m.Config.Sources, &m.Config.Sources.AwsParameterStore, &m.Config.Sources.AwsParameterStore.DefaultKey), ".")
 	sourceKey := strings.Join(tagsForFields(&p, &p.Source, &p.Source.AwsParameterStore, &p.Source.AwsParameterStore.Key), ".")
-	return fmt.Errorf("key missing for %s, set %s or %s", api.SourceTypeAwsParameterStore, configKey, sourceKey)
+	return missingKeyError(api.SourceTypeAwsParameterStore, configKey, sourceKey)
 }
 
 func tagsForFields(fields ...interface{}) (tags []string) {
@@ -165,6 +394,9 @@ func tagsForFields(fields ...interface{}) (tags []string) {
 				fv = fv.Elem()
 			}
 			for i := 0; i < fv.NumField(); i++ {
+				if !fv.Type().Field(i).IsExported() {
+					continue
+				}
 				if fv.Field(i).Addr().Interface() == nfv {
 					tag := fv.Type().Field(i).Tag.Get("yaml")
 					tag = strings.ReplaceAll(tag, ",omitempty", "")
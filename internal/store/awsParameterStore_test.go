@@ -0,0 +1,253 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeParameterStore serves parameters from memory and records the calls made
+type fakeParameterStore struct {
+	store.ParameterStoreClient
+	mu                  sync.Mutex
+	parameters          map[string]string
+	getParameter        []string
+	getParameters       [][]string
+	getParametersByPath []string
+}
+
+func (f *fakeParameterStore) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getParameter = append(f.getParameter, *params.Name)
+
+	v, ok := f.parameters[*params.Name]
+	if !ok {
+		return nil, &ssmtypes.ParameterNotFound{Message: aws.String(fmt.Sprintf("%s does not exist", *params.Name))}
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Name: params.Name, Value: aws.String(v)}}, nil
+}
+
+func (f *fakeParameterStore) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getParameters = append(f.getParameters, params.Names)
+
+	if len(params.Names) > 10 {
+		return nil, fmt.Errorf("at most 10 names are allowed")
+	}
+
+	out := &ssm.GetParametersOutput{}
+	for _, n := range params.Names {
+		if v, ok := f.parameters[n]; ok {
+			// parameters are returned by name, requested selectors are returned separately
+			name, selector := n, ""
+			if i := strings.LastIndex(n, ":"); i >= 0 {
+				name, selector = n[:i], n[i:]
+			}
+			out.Parameters = append(out.Parameters, ssmtypes.Parameter{Name: aws.String(name), Selector: aws.String(selector), Value: aws.String(v)})
+		} else {
+			out.InvalidParameters = append(out.InvalidParameters, n)
+		}
+	}
+	return out, nil
+}
+
+// GetParametersByPath returns a single parameter per page to cover pagination
+func (f *fakeParameterStore) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if params.NextToken == nil {
+		f.getParametersByPath = append(f.getParametersByPath, *params.Path)
+	}
+
+	names := make([]string, 0)
+	for n := range f.parameters {
+		if path.Dir(n) == *params.Path {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	start := 0
+	if params.NextToken != nil {
+		fmt.Sscan(*params.NextToken, &start)
+	}
+	out := &ssm.GetParametersByPathOutput{}
+	if start < len(names) {
+		out.Parameters = []ssmtypes.Parameter{{Name: aws.String(names[start]), Value: aws.String(f.parameters[names[start]])}}
+		if start+1 < len(names) {
+			out.NextToken = aws.String(fmt.Sprint(start + 1))
+		}
+	}
+	return out, nil
+}
+
+var _ = Describe("AwsParameterStore", func() {
+	var ctx config.AppContext
+	var layer api.Layer
+	var client *fakeParameterStore
+	var ps *store.AwsParameterStore
+
+	BeforeEach(func() {
+		ctx = config.AppContext{
+			Context: context.Background(),
+			Log:     logrus.New(),
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+		client = &fakeParameterStore{parameters: map[string]string{"/myapp/dev/password": "s3cr3t"}}
+		ps = store.NewAwsParameterStore(client)
+	})
+
+	read := func(key string, source config.ValueFromAwsParameterStore, sourceConfig config.AwsParameterStoreConfig) api.Value {
+		if len(source.Key) == 0 {
+			source.Key = "/myapp/dev/{key}"
+		}
+		return ps.Read(ctx, layer, key, false, source, sourceConfig)
+	}
+
+	It("reads parameters not prefetched using GetParameter", func() {
+		val := read("Password", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("s3cr3t"))
+		Expect(val.Key()).To(Equal("/myapp/dev/password"))
+		Expect(client.getParameter).To(Equal([]string{"/myapp/dev/password"}))
+	})
+
+	It("prefetches parameters in batches of 10 and reads them from the cache", func() {
+		keys := make([]string, 0)
+		for i := 0; i < 23; i++ {
+			k := fmt.Sprintf("/myapp/p%d/value", i)
+			client.parameters[k] = fmt.Sprint(i)
+			keys = append(keys, k)
+		}
+		keys = append(keys, keys[0])
+
+		Expect(ps.Prefetch(ctx, keys)).To(Succeed())
+		Expect(client.getParameters).To(HaveLen(3))
+		Expect(client.getParameters[0]).To(HaveLen(10))
+		Expect(client.getParameters[1]).To(HaveLen(10))
+		Expect(client.getParameters[2]).To(HaveLen(3))
+		Expect(client.getParametersByPath).To(BeEmpty())
+
+		for i := 0; i < 23; i++ {
+			val := read("Value", config.ValueFromAwsParameterStore{Key: fmt.Sprintf("/myapp/p%d/{key}", i)}, config.AwsParameterStoreConfig{})
+			Expect(val.Raw()).To(Equal(fmt.Sprint(i)))
+		}
+		Expect(client.getParameter).To(BeEmpty())
+
+		Expect(ps.Prefetch(ctx, keys)).To(Succeed())
+		Expect(client.getParameters).To(HaveLen(3))
+	})
+
+	It("prefetches parameters sharing a path using GetParametersByPath", func() {
+		keys := []string{"/myapp/dev/password", "/myapp/dev/missing"}
+		for i := 0; i < 10; i++ {
+			k := fmt.Sprintf("/myapp/dev/value%d", i)
+			client.parameters[k] = fmt.Sprint(i)
+			keys = append(keys, k)
+		}
+
+		Expect(ps.Prefetch(ctx, keys)).To(Succeed())
+		Expect(client.getParametersByPath).To(Equal([]string{"/myapp/dev"}))
+		Expect(client.getParameters).To(BeEmpty())
+
+		Expect(read("Value", config.ValueFromAwsParameterStore{Key: "/myapp/dev/value9"}, config.AwsParameterStoreConfig{}).Raw()).To(Equal("9"))
+		Expect(read("Password", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{}).Raw()).To(Equal("s3cr3t"))
+		Expect(api.IsNotFoundError(read("Missing", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{}).Error())).To(BeTrue())
+		Expect(client.getParameter).To(BeEmpty())
+	})
+
+	It("prefetches parameters using selectors next to the same parameter without selector", func() {
+		client.parameters["/myapp/dev/password:1"] = "initial"
+		client.parameters["/myapp/dev/password:latest"] = "labeled"
+
+		Expect(ps.Prefetch(ctx, []string{"/myapp/dev/password:1", "/myapp/dev/password", "/myapp/dev/password:latest"})).To(Succeed())
+		Expect(client.getParameters).To(HaveLen(1))
+
+		Expect(read("Password", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{}).Raw()).To(Equal("s3cr3t"))
+		Expect(read("Password", config.ValueFromAwsParameterStore{Key: "/myapp/dev/{key}:1"}, config.AwsParameterStoreConfig{}).Raw()).To(Equal("initial"))
+		Expect(read("Password", config.ValueFromAwsParameterStore{Key: "/myapp/dev/{key}:latest"}, config.AwsParameterStoreConfig{}).Raw()).To(Equal("labeled"))
+		Expect(client.getParameter).To(BeEmpty())
+	})
+
+	Describe("not found", func() {
+		treatAsError := true
+
+		It("returns not found error wrapping the parameter store error", func() {
+			val := read("Missing", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{})
+			Expect(api.IsNotFoundError(val.Error())).To(BeTrue())
+
+			var notFound *api.NotFoundError
+			Expect(errors.As(val.Error(), &notFound)).To(BeTrue())
+			Expect(notFound.InnerError()).To(MatchError("ParameterNotFound: /myapp/dev/missing does not exist"))
+			Expect(notFound.Error()).To(Equal("NotFoundError, value not found, see inner error for more details (source=awsParameterStore key=/myapp/dev/missing)"))
+		})
+
+		It("returns error when configured to treat not found as error", func() {
+			val := read("Missing", config.ValueFromAwsParameterStore{TreatNotFoundAsError: &treatAsError}, config.AwsParameterStoreConfig{})
+			Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+			Expect(val.Error()).To(MatchError("/myapp/dev/missing not found in awsParameterStore, configured to be treated as an error, ParameterNotFound: /myapp/dev/missing does not exist"))
+
+			val = read("Missing", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{TreatNotFoundAsError: true})
+			Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+		})
+
+		It("returns the same errors for prefetched parameters", func() {
+			Expect(ps.Prefetch(ctx, []string{"/myapp/dev/missing"})).To(Succeed())
+			Expect(client.getParameters).To(Equal([][]string{{"/myapp/dev/missing"}}))
+
+			val := read("Missing", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{})
+			Expect(api.IsNotFoundError(val.Error())).To(BeTrue())
+
+			var notFound *api.NotFoundError
+			Expect(errors.As(val.Error(), &notFound)).To(BeTrue())
+			var inner *ssmtypes.ParameterNotFound
+			Expect(errors.As(notFound.InnerError(), &inner)).To(BeTrue())
+
+			val = read("Missing", config.ValueFromAwsParameterStore{TreatNotFoundAsError: &treatAsError}, config.AwsParameterStoreConfig{})
+			Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+			Expect(val.Error().Error()).To(HavePrefix("/myapp/dev/missing not found in awsParameterStore, configured to be treated as an error, ParameterNotFound:"))
+			Expect(client.getParameter).To(BeEmpty())
+		})
+	})
+
+	It("does not cache errors other than not found", func() {
+		failing := &failingParameterStore{fakeParameterStore: client, failing: true}
+		ps = store.NewAwsParameterStore(failing)
+		val := read("Password", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{})
+		Expect(val.Error()).To(MatchError(ContainSubstring("throttled")))
+		Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+
+		failing.failing = false
+		Expect(read("Password", config.ValueFromAwsParameterStore{}, config.AwsParameterStoreConfig{}).Raw()).To(Equal("s3cr3t"))
+	})
+})
+
+type failingParameterStore struct {
+	*fakeParameterStore
+	failing bool
+}
+
+func (f *failingParameterStore) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	if f.failing {
+		return nil, fmt.Errorf("throttled")
+	}
+	return f.fakeParameterStore.GetParameter(ctx, params, optFns...)
+}
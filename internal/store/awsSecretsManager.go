@@ -0,0 +1,345 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/environment"
+)
+
+func newAwsSecretsManager(ctx context.Context) (*AwsSecretsManager, error) {
+	client, err := newSecretsManagerClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAwsSecretsManager(client), nil
+}
+
+// NewAwsSecretsManager creates a secrets manager source using the provided client
+func NewAwsSecretsManager(client SecretsManagerClient) *AwsSecretsManager {
+	return &AwsSecretsManager{
+		client: client,
+	}
+}
+
+// SecretsManagerClient is the subset of the Secrets Manager client used by the secrets manager source
+type SecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
+	UpdateSecret(ctx context.Context, params *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error)
+	PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error)
+	TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error)
+	DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error)
+}
+
+type AwsSecretsManager struct {
+	client SecretsManagerClient
+}
+
+func (s *AwsSecretsManager) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromAwsSecretsManager, sourceConfig config.AwsSecretsManagerConfig) api.Value {
+	smkf := sourceConfig.DefaultKey
+	if len(propertySource.Key) > 0 {
+		smkf = propertySource.Key
+	}
+
+	if len(smkf) == 0 {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), "", "", missingSecretsManagerKeyError(), sensitive || sourceConfig.ForceSensitive)
+	}
+
+	name := awpParameterStoreKey(ctx.Replace(smkf), key)
+	smk := joinKeyField(name, propertySource.JsonKey)
+	ctx.Log.Debugf("reading %s from %s", smk, config.SourceTypeAwsSecretsManager)
+	out, err := s.client.GetSecretValue(ctx.Context, &secretsmanager.GetSecretValueInput{
+		SecretId: &name,
+	})
+	if err != nil {
+		var notFound *smtypes.ResourceNotFoundException
+		if !errors.As(err, &notFound) {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, "", err, sensitive || sourceConfig.ForceSensitive)
+		}
+		return s.notFound(ctx, layer, smk, sensitive, notFound, propertySource, sourceConfig)
+	}
+
+	if out.SecretString == nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, "", fmt.Errorf("%s in %s is a binary secret, only string secrets are supported", name, config.SourceTypeAwsSecretsManager), sensitive || sourceConfig.ForceSensitive)
+	}
+
+	if len(propertySource.JsonKey) == 0 {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, *out.SecretString, nil, sensitive || sourceConfig.ForceSensitive)
+	}
+
+	fields, err := secretJsonFields(*out.SecretString)
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, "", fmt.Errorf("failed to select json key %s from %s, %v", propertySource.JsonKey, name, err), sensitive || sourceConfig.ForceSensitive)
+	}
+
+	field, ok := fields[propertySource.JsonKey]
+	if !ok {
+		return s.notFound(ctx, layer, smk, sensitive, fmt.Errorf("json key %s not found in secret %s", propertySource.JsonKey, name), propertySource, sourceConfig)
+	}
+
+	switch fv := field.(type) {
+	case string:
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, fv, nil, sensitive || sourceConfig.ForceSensitive)
+	default:
+		b, err := json.Marshal(fv)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, string(b), err, sensitive || sourceConfig.ForceSensitive)
+	}
+}
+
+func (s *AwsSecretsManager) notFound(ctx config.AppContext, layer api.Layer, smk string, sensitive bool, inner error, propertySource config.ValueFromAwsSecretsManager, sourceConfig config.AwsSecretsManagerConfig) api.Value {
+	treatAsError := sourceConfig.TreatNotFoundAsError
+	if propertySource.TreatNotFoundAsError != nil {
+		treatAsError = *propertySource.TreatNotFoundAsError
+	}
+	if treatAsError {
+		ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", smk, config.SourceTypeAwsSecretsManager)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, "", fmt.Errorf("%s not found in %s, configured to be treated as an error, %v", smk, config.SourceTypeAwsSecretsManager, inner), sensitive || sourceConfig.ForceSensitive)
+	}
+	ctx.Log.Debugf("%s not found in %s", smk, config.SourceTypeAwsSecretsManager)
+	return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), smk, "", api.NewNotFoundError(inner, smk, api.SourceTypeAwsSecretsManager), sensitive || sourceConfig.ForceSensitive)
+}
+
+func (s *AwsSecretsManager) Write(ctx config.AppContext, key, value, description string, sourceConfig config.AwsSecretsManagerConfig) error {
+	name, jsonKey := splitKeyField(key)
+	ctx.Log.Infof("upserting secret %s in %s", key, api.SourceTypeAwsSecretsManager)
+
+	exists := true
+	current, err := s.client.GetSecretValue(ctx.Context, &secretsmanager.GetSecretValueInput{
+		SecretId: &name,
+	})
+	if err != nil {
+		var notFound *smtypes.ResourceNotFoundException
+		if !errors.As(err, &notFound) {
+			ctx.Log.Errorf("failed to read secret %s in %s, %v", name, config.SourceTypeAwsSecretsManager, err)
+			return err
+		}
+		exists = false
+	}
+
+	secretString := value
+	if len(jsonKey) > 0 {
+		fields := make(map[string]interface{})
+		if exists && current.SecretString != nil {
+			fields, err = secretJsonFields(*current.SecretString)
+			if err != nil {
+				return fmt.Errorf("failed to set json key %s in %s, %v", jsonKey, name, err)
+			}
+		}
+		fields[jsonKey] = value
+		secretString, err = secretJsonString(fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	tags := []smtypes.Tag{}
+	for _, t := range resourceTags(ctx) {
+		tags = append(tags, smtypes.Tag{
+			Key:   aws.String(t.key),
+			Value: aws.String(t.value),
+		})
+	}
+
+	if !exists {
+		i := secretsmanager.CreateSecretInput{
+			Name:         &name,
+			Description:  &description,
+			SecretString: &secretString,
+			Tags:         tags,
+		}
+
+		if sourceConfig.KmsKey != "" {
+			i.KmsKeyId = &sourceConfig.KmsKey
+		}
+
+		if _, err := s.client.CreateSecret(ctx.Context, &i); err != nil {
+			ctx.Log.Errorf("failed to create secret %s in %s, %v", name, config.SourceTypeAwsSecretsManager, err)
+			return err
+		}
+		return nil
+	}
+
+	i := secretsmanager.UpdateSecretInput{
+		SecretId:     &name,
+		Description:  &description,
+		SecretString: &secretString,
+	}
+
+	if sourceConfig.KmsKey != "" {
+		i.KmsKeyId = &sourceConfig.KmsKey
+	}
+
+	if _, err := s.client.UpdateSecret(ctx.Context, &i); err != nil {
+		ctx.Log.Errorf("failed to update secret %s in %s, %v", name, config.SourceTypeAwsSecretsManager, err)
+		return err
+	}
+
+	if _, err := s.client.TagResource(ctx.Context, &secretsmanager.TagResourceInput{
+		SecretId: &name,
+		Tags:     tags,
+	}); err != nil {
+		ctx.Log.Errorf("failed to tag secret %s in %s, %v", name, config.SourceTypeAwsSecretsManager, err)
+		return err
+	}
+
+	return nil
+}
+
+// ReadKey reads a secret, or a json key of a secret, by its source key
+func (s *AwsSecretsManager) ReadKey(ctx config.AppContext, key string) (string, bool, error) {
+	name, jsonKey := splitKeyField(key)
+	out, err := s.client.GetSecretValue(ctx.Context, &secretsmanager.GetSecretValueInput{
+		SecretId: &name,
+	})
+	if err != nil {
+		var notFound *smtypes.ResourceNotFoundException
+		if !errors.As(err, &notFound) {
+			return "", false, err
+		}
+		return "", false, nil
+	}
+
+	if out.SecretString == nil {
+		return "", false, fmt.Errorf("%s in %s is a binary secret, only string secrets are supported", name, config.SourceTypeAwsSecretsManager)
+	}
+
+	if len(jsonKey) == 0 {
+		return *out.SecretString, true, nil
+	}
+
+	fields, err := secretJsonFields(*out.SecretString)
+	if err != nil {
+		return "", false, err
+	}
+	field, ok := fields[jsonKey]
+	if !ok {
+		return "", false, nil
+	}
+	if fv, ok := field.(string); ok {
+		return fv, true, nil
+	}
+	b, err := json.Marshal(field)
+	return string(b), true, err
+}
+
+// Delete deletes a secret, scheduled for deletion using the default recovery window, or removes a json key from a secret.
+// A secret is deleted when its last json key is removed.
+func (s *AwsSecretsManager) Delete(ctx config.AppContext, key string) error {
+	name, jsonKey := splitKeyField(key)
+
+	if len(jsonKey) > 0 {
+		current, err := s.client.GetSecretValue(ctx.Context, &secretsmanager.GetSecretValueInput{
+			SecretId: &name,
+		})
+		if err != nil {
+			var notFound *smtypes.ResourceNotFoundException
+			if errors.As(err, &notFound) {
+				ctx.Log.Debugf("secret %s not found in %s, nothing to delete", name, config.SourceTypeAwsSecretsManager)
+				return nil
+			}
+			return err
+		}
+
+		fields := make(map[string]interface{})
+		if current.SecretString != nil {
+			fields, err = secretJsonFields(*current.SecretString)
+			if err != nil {
+				return fmt.Errorf("failed to remove json key %s from %s, %v", jsonKey, name, err)
+			}
+		}
+
+		if _, ok := fields[jsonKey]; !ok {
+			ctx.Log.Debugf("json key %s not found in secret %s, nothing to delete", jsonKey, name)
+			return nil
+		}
+		delete(fields, jsonKey)
+
+		if len(fields) > 0 {
+			ctx.Log.Infof("removing json key %s from secret %s in %s", jsonKey, name, api.SourceTypeAwsSecretsManager)
+			secretString, err := secretJsonString(fields)
+			if err != nil {
+				return err
+			}
+			if _, err := s.client.PutSecretValue(ctx.Context, &secretsmanager.PutSecretValueInput{
+				SecretId:     &name,
+				SecretString: &secretString,
+			}); err != nil {
+				ctx.Log.Errorf("failed to update secret %s in %s, %v", name, config.SourceTypeAwsSecretsManager, err)
+				return err
+			}
+			return nil
+		}
+	}
+
+	ctx.Log.Infof("deleting secret %s in %s", name, api.SourceTypeAwsSecretsManager)
+	if _, err := s.client.DeleteSecret(ctx.Context, &secretsmanager.DeleteSecretInput{
+		SecretId: &name,
+	}); err != nil {
+		var notFound *smtypes.ResourceNotFoundException
+		if !errors.As(err, &notFound) {
+			ctx.Log.Errorf("failed to delete secret %s in %s, %v", name, config.SourceTypeAwsSecretsManager, err)
+			return err
+		}
+		ctx.Log.Debugf("secret %s not found in %s, nothing to delete", name, config.SourceTypeAwsSecretsManager)
+	}
+	return nil
+}
+
+func newSecretsManagerClient(ctx context.Context) (*secretsmanager.Client, error) {
+	if awsRegion := environment.StringVar("AWS_REGION", ""); awsRegion == "" {
+		return nil, fmt.Errorf("required environment variable AWS_REGION has no value set")
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return secretsmanager.NewFromConfig(awsConfig), nil
+}
+
+// secretJsonFields parses the json object of a secret, numbers are kept as written so that updating
+// a single key leaves the other fields of the secret unchanged
+func secretJsonFields(secretString string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	d := json.NewDecoder(strings.NewReader(secretString))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("secret string is not a json object, %v", err)
+	}
+	return fields, nil
+}
+
+func secretJsonString(fields map[string]interface{}) (string, error) {
+	b := &strings.Builder{}
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(fields); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// NOTE: Really ugly hack to avoid magic strings, poor performance expected
+func missingSecretsManagerKeyError() error {
+	m := config.Manifest{}
+	p := config.PropertyConfig{
+		Source: &config.ValueSourceConfig{
+			AwsSecretsManager: &config.ValueFromAwsSecretsManager{},
+		},
+	}
+	configKey := strings.Join(tagsForFields(&m, &m.Config, &m.Config.Sources, &m.Config.Sources.AwsSecretsManager, &m.Config.Sources.AwsSecretsManager.DefaultKey), ".")
+	sourceKey := strings.Join(tagsForFields(&p, &p.Source, &p.Source.AwsSecretsManager, &p.Source.AwsSecretsManager.Key), ".")
+	return missingKeyError(api.SourceTypeAwsSecretsManager, configKey, sourceKey)
+}
@@ -0,0 +1,249 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSecretsManager serves secrets from memory and records the calls made
+type fakeSecretsManager struct {
+	mu      sync.Mutex
+	secrets map[string]string
+	tags    map[string]map[string]string
+	created []string
+	updated []string
+	deleted []string
+}
+
+func newFakeSecretsManager() *fakeSecretsManager {
+	return &fakeSecretsManager{
+		secrets: make(map[string]string),
+		tags:    make(map[string]map[string]string),
+	}
+}
+
+func (f *fakeSecretsManager) notFound(name string) error {
+	return &smtypes.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("secret %s not found", name))}
+}
+
+func (f *fakeSecretsManager) tag(name string, tags []smtypes.Tag) {
+	if f.tags[name] == nil {
+		f.tags[name] = make(map[string]string)
+	}
+	for _, t := range tags {
+		f.tags[name][*t.Key] = *t.Value
+	}
+}
+
+func (f *fakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.secrets[*params.SecretId]
+	if !ok {
+		return nil, f.notFound(*params.SecretId)
+	}
+	return &secretsmanager.GetSecretValueOutput{Name: params.SecretId, SecretString: aws.String(v)}, nil
+}
+
+func (f *fakeSecretsManager) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.secrets[*params.Name]; ok {
+		return nil, &smtypes.ResourceExistsException{Message: aws.String("secret exists")}
+	}
+	f.created = append(f.created, *params.Name)
+	f.secrets[*params.Name] = *params.SecretString
+	f.tag(*params.Name, params.Tags)
+	return &secretsmanager.CreateSecretOutput{Name: params.Name}, nil
+}
+
+func (f *fakeSecretsManager) UpdateSecret(ctx context.Context, params *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.secrets[*params.SecretId]; !ok {
+		return nil, f.notFound(*params.SecretId)
+	}
+	f.updated = append(f.updated, *params.SecretId)
+	f.secrets[*params.SecretId] = *params.SecretString
+	return &secretsmanager.UpdateSecretOutput{Name: params.SecretId}, nil
+}
+
+func (f *fakeSecretsManager) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.secrets[*params.SecretId]; !ok {
+		return nil, f.notFound(*params.SecretId)
+	}
+	f.updated = append(f.updated, *params.SecretId)
+	f.secrets[*params.SecretId] = *params.SecretString
+	return &secretsmanager.PutSecretValueOutput{Name: params.SecretId}, nil
+}
+
+func (f *fakeSecretsManager) TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.secrets[*params.SecretId]; !ok {
+		return nil, f.notFound(*params.SecretId)
+	}
+	f.tag(*params.SecretId, params.Tags)
+	return &secretsmanager.TagResourceOutput{}, nil
+}
+
+func (f *fakeSecretsManager) DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.secrets[*params.SecretId]; !ok {
+		return nil, f.notFound(*params.SecretId)
+	}
+	f.deleted = append(f.deleted, *params.SecretId)
+	delete(f.secrets, *params.SecretId)
+	return &secretsmanager.DeleteSecretOutput{Name: params.SecretId}, nil
+}
+
+var _ = Describe("AwsSecretsManager", func() {
+	var ctx config.AppContext
+	var layer api.Layer
+	var client *fakeSecretsManager
+	var sm *store.AwsSecretsManager
+
+	BeforeEach(func() {
+		ctx = config.AppContext{
+			Context:  context.Background(),
+			Log:      logrus.New(),
+			Metadata: config.AppMetadata{Version: "test"},
+			Manifest: config.Manifest{MetadataConfig: config.MetadataConfig{Name: "myapp"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+		client = newFakeSecretsManager()
+		client.secrets["myapp/dev/database"] = `{"password":"s3cr3t","port":5432,"ssl":true,"replicas":["a","b"],"limits":{"max":18446744073709551615}}`
+		client.secrets["myapp/dev/api_key"] = "key"
+		sm = store.NewAwsSecretsManager(client)
+	})
+
+	read := func(key string, source config.ValueFromAwsSecretsManager, sourceConfig config.AwsSecretsManagerConfig) api.Value {
+		source.Key = "myapp/dev/{key}"
+		return sm.Read(ctx, layer, key, false, source, sourceConfig)
+	}
+
+	Describe("Read", func() {
+		It("reads the secret string", func() {
+			val := read("ApiKey", config.ValueFromAwsSecretsManager{}, config.AwsSecretsManagerConfig{})
+			Expect(val.Error()).To(Not(HaveOccurred()))
+			Expect(val.Raw()).To(Equal("key"))
+			Expect(val.Key()).To(Equal("myapp/dev/api_key"))
+		})
+
+		It("reads json keys, non string fields as json", func() {
+			val := read("Database", config.ValueFromAwsSecretsManager{JsonKey: "password"}, config.AwsSecretsManagerConfig{})
+			Expect(val.Error()).To(Not(HaveOccurred()))
+			Expect(val.Raw()).To(Equal("s3cr3t"))
+			Expect(val.Key()).To(Equal("myapp/dev/database#password"))
+
+			Expect(read("Database", config.ValueFromAwsSecretsManager{JsonKey: "port"}, config.AwsSecretsManagerConfig{}).Raw()).To(Equal("5432"))
+			Expect(read("Database", config.ValueFromAwsSecretsManager{JsonKey: "limits"}, config.AwsSecretsManagerConfig{}).Raw()).To(Equal(`{"max":18446744073709551615}`))
+		})
+
+		It("returns not found error for missing secrets and json keys", func() {
+			Expect(api.IsNotFoundError(read("Missing", config.ValueFromAwsSecretsManager{}, config.AwsSecretsManagerConfig{}).Error())).To(BeTrue())
+			Expect(api.IsNotFoundError(read("Database", config.ValueFromAwsSecretsManager{JsonKey: "username"}, config.AwsSecretsManagerConfig{}).Error())).To(BeTrue())
+		})
+
+		It("returns error for missing secrets when configured to treat not found as error", func() {
+			val := read("Missing", config.ValueFromAwsSecretsManager{}, config.AwsSecretsManagerConfig{TreatNotFoundAsError: true})
+			Expect(val.Error()).To(HaveOccurred())
+			Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+		})
+
+		It("returns errors describing missing json keys", func() {
+			val := read("Database", config.ValueFromAwsSecretsManager{JsonKey: "username"}, config.AwsSecretsManagerConfig{})
+			var notFound *api.NotFoundError
+			Expect(errors.As(val.Error(), &notFound)).To(BeTrue())
+			Expect(notFound.InnerError()).To(MatchError("json key username not found in secret myapp/dev/database"))
+
+			val = read("Database", config.ValueFromAwsSecretsManager{JsonKey: "username"}, config.AwsSecretsManagerConfig{TreatNotFoundAsError: true})
+			Expect(val.Error()).To(MatchError("myapp/dev/database#username not found in awsSecretsManager, configured to be treated as an error, json key username not found in secret myapp/dev/database"))
+		})
+	})
+
+	Describe("Write", func() {
+		It("creates new secrets with tags", func() {
+			Expect(sm.Write(ctx, "myapp/dev/new", "value", "New secret", config.AwsSecretsManagerConfig{})).To(Succeed())
+			Expect(client.created).To(Equal([]string{"myapp/dev/new"}))
+			Expect(client.updated).To(BeEmpty())
+			Expect(client.secrets["myapp/dev/new"]).To(Equal("value"))
+			Expect(client.tags["myapp/dev/new"]).To(HaveKeyWithValue("racoon/owner", "myapp"))
+			Expect(client.tags["myapp/dev/new"]).To(HaveKeyWithValue("racoon/version", "test"))
+		})
+
+		It("creates new secrets with a json key", func() {
+			Expect(sm.Write(ctx, "myapp/dev/new#password", "s3cr3t", "New secret", config.AwsSecretsManagerConfig{})).To(Succeed())
+			Expect(client.secrets["myapp/dev/new"]).To(Equal(`{"password":"s3cr3t"}`))
+		})
+
+		It("updates existing secrets and tags them", func() {
+			Expect(sm.Write(ctx, "myapp/dev/api_key", "n3w", "Api key", config.AwsSecretsManagerConfig{})).To(Succeed())
+			Expect(client.created).To(BeEmpty())
+			Expect(client.updated).To(Equal([]string{"myapp/dev/api_key"}))
+			Expect(client.secrets["myapp/dev/api_key"]).To(Equal("n3w"))
+			Expect(client.tags["myapp/dev/api_key"]).To(HaveKeyWithValue("racoon/owner", "myapp"))
+		})
+
+		It("updates a json key and keeps other fields unchanged", func() {
+			Expect(sm.Write(ctx, "myapp/dev/database#password", "n3w&<>", "Database", config.AwsSecretsManagerConfig{})).To(Succeed())
+			Expect(client.secrets["myapp/dev/database"]).To(MatchJSON(`{"password":"n3w&<>","port":5432,"ssl":true,"replicas":["a","b"],"limits":{"max":18446744073709551615}}`))
+			Expect(client.secrets["myapp/dev/database"]).To(ContainSubstring(`18446744073709551615`))
+
+			value, found, err := sm.ReadKey(ctx, "myapp/dev/database#password")
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("n3w&<>"))
+		})
+
+		It("returns error when setting a json key of a secret that is not a json object", func() {
+			Expect(sm.Write(ctx, "myapp/dev/api_key#value", "n3w", "Api key", config.AwsSecretsManagerConfig{})).ToNot(Succeed())
+			Expect(client.secrets["myapp/dev/api_key"]).To(Equal("key"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes a json key and keeps other fields unchanged", func() {
+			Expect(sm.Delete(ctx, "myapp/dev/database#password")).To(Succeed())
+			Expect(client.deleted).To(BeEmpty())
+			Expect(client.secrets["myapp/dev/database"]).To(MatchJSON(`{"port":5432,"ssl":true,"replicas":["a","b"],"limits":{"max":18446744073709551615}}`))
+			Expect(client.secrets["myapp/dev/database"]).To(ContainSubstring(`18446744073709551615`))
+		})
+
+		It("deletes the secret when removing the last json key", func() {
+			client.secrets["myapp/dev/single"] = `{"password":"s3cr3t"}`
+			Expect(sm.Delete(ctx, "myapp/dev/single#password")).To(Succeed())
+			Expect(client.deleted).To(Equal([]string{"myapp/dev/single"}))
+		})
+
+		It("deletes secrets", func() {
+			Expect(sm.Delete(ctx, "myapp/dev/api_key")).To(Succeed())
+			Expect(client.secrets).ToNot(HaveKey("myapp/dev/api_key"))
+		})
+
+		It("ignores missing secrets and json keys", func() {
+			Expect(sm.Delete(ctx, "myapp/dev/missing")).To(Succeed())
+			Expect(sm.Delete(ctx, "myapp/dev/missing#password")).To(Succeed())
+			Expect(sm.Delete(ctx, "myapp/dev/database#username")).To(Succeed())
+			Expect(client.deleted).To(BeEmpty())
+			Expect(client.updated).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,289 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/environment"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	encryptedFileDefaultKey    = "{key}"
+	encryptedFileDefaultKeyEnv = "RACOON_ENCRYPTED_FILE_KEY"
+	encryptedFileVersion       = 1
+	encryptedFileCipher        = "aes-256-gcm"
+	encryptedFileHeader        = "# managed by racoon, values are encrypted and must not be edited by hand\n"
+)
+
+func newEncryptedFile() (*EncryptedFile, error) {
+	return &EncryptedFile{}, nil
+}
+
+// EncryptedFile reads and writes values encrypted at rest in a local yaml file. Values are encrypted one by one
+// using AES-256-GCM and a symmetric key from the environment, keys are kept in clear text to make changes reviewable.
+type EncryptedFile struct {
+	mu sync.Mutex
+}
+
+type encryptedFileDocument struct {
+	Racoon encryptedFileMetadata `yaml:"racoon"`
+	Values map[string]string     `yaml:"values"`
+}
+
+type encryptedFileMetadata struct {
+	Version int    `yaml:"version"`
+	Cipher  string `yaml:"cipher"`
+}
+
+func (s *EncryptedFile) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromEncryptedFile, sourceConfig config.EncryptedFileConfig) api.Value {
+	sensitive = sensitive || sourceConfig.ForceSensitive
+
+	path := sourceConfig.Path
+	if len(propertySource.Path) > 0 {
+		path = propertySource.Path
+	}
+	if len(path) == 0 {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeEncryptedFile), "", "", missingEncryptedFilePathError(), sensitive)
+	}
+	path = ctx.Replace(path)
+
+	ekf := encryptedFileDefaultKey
+	if len(sourceConfig.DefaultKey) > 0 {
+		ekf = sourceConfig.DefaultKey
+	}
+	if len(propertySource.Key) > 0 {
+		ekf = propertySource.Key
+	}
+
+	entry := awpParameterStoreKey(ctx.Replace(ekf), key)
+	ek := joinKeyField(path, entry)
+	ctx.Log.Debugf("reading %s from %s", ek, config.SourceTypeEncryptedFile)
+
+	value, found, err := s.ReadKey(ctx, ek, sourceConfig)
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeEncryptedFile), ek, "", err, sensitive)
+	}
+
+	if !found {
+		treatAsError := sourceConfig.TreatNotFoundAsError
+		if propertySource.TreatNotFoundAsError != nil {
+			treatAsError = *propertySource.TreatNotFoundAsError
+		}
+		if treatAsError {
+			ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", ek, config.SourceTypeEncryptedFile)
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeEncryptedFile), ek, "", fmt.Errorf("%s not found in %s, configured to be treated as an error", ek, config.SourceTypeEncryptedFile), sensitive)
+		}
+		ctx.Log.Debugf("%s not found in %s", ek, config.SourceTypeEncryptedFile)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeEncryptedFile), ek, "", api.NewNotFoundError(nil, ek, api.SourceTypeEncryptedFile), sensitive)
+	}
+
+	return api.NewValue(api.NewValueSource(layer, api.SourceTypeEncryptedFile), ek, value, nil, sensitive)
+}
+
+func (s *EncryptedFile) Write(ctx config.AppContext, key, value, description string, sourceConfig config.EncryptedFileConfig) error {
+	path, entry := splitKeyField(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx.Log.Infof("upserting value %s in %s", key, api.SourceTypeEncryptedFile)
+
+	doc, err := readEncryptedFile(path)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		doc = newEncryptedFileDocument()
+	}
+
+	aead, err := encryptedFileAEAD(sourceConfig)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	// the entry is authenticated with the value, preventing encrypted values from being moved between entries
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(entry))
+	doc.Values[entry] = base64.StdEncoding.EncodeToString(sealed)
+
+	return writeEncryptedFile(path, doc)
+}
+
+// ReadKey reads and decrypts an entry of a file by its source key
+func (s *EncryptedFile) ReadKey(ctx config.AppContext, key string, sourceConfig config.EncryptedFileConfig) (string, bool, error) {
+	path, entry := splitKeyField(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := readEncryptedFile(path)
+	if err != nil || doc == nil {
+		return "", false, err
+	}
+
+	ev, ok := doc.Values[entry]
+	if !ok {
+		return "", false, nil
+	}
+
+	aead, err := encryptedFileAEAD(sourceConfig)
+	if err != nil {
+		return "", false, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ev)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", false, fmt.Errorf("failed to decrypt %s, value is malformed", key)
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(entry))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt %s, the key does not match the key used for encryption or the value has been modified", key)
+	}
+
+	return string(plain), true, nil
+}
+
+// Delete removes an entry from a file, the file is kept when the last entry is removed
+func (s *EncryptedFile) Delete(ctx config.AppContext, key string) error {
+	path, entry := splitKeyField(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := readEncryptedFile(path)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		ctx.Log.Debugf("file %s not found in %s, nothing to delete", path, config.SourceTypeEncryptedFile)
+		return nil
+	}
+	if _, ok := doc.Values[entry]; !ok {
+		ctx.Log.Debugf("value %s not found in %s, nothing to delete", key, config.SourceTypeEncryptedFile)
+		return nil
+	}
+
+	ctx.Log.Infof("deleting value %s in %s", key, api.SourceTypeEncryptedFile)
+	delete(doc.Values, entry)
+
+	return writeEncryptedFile(path, doc)
+}
+
+func newEncryptedFileDocument() *encryptedFileDocument {
+	return &encryptedFileDocument{
+		Racoon: encryptedFileMetadata{
+			Version: encryptedFileVersion,
+			Cipher:  encryptedFileCipher,
+		},
+		Values: make(map[string]string),
+	}
+}
+
+// readEncryptedFile returns the parsed file, or nil when the file does not exist
+func readEncryptedFile(path string) (*encryptedFileDocument, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encrypted file %s, %v", path, err)
+	}
+
+	doc := newEncryptedFileDocument()
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted file %s, %v", path, err)
+	}
+	if doc.Racoon.Version != encryptedFileVersion || doc.Racoon.Cipher != encryptedFileCipher {
+		return nil, fmt.Errorf("unsupported encrypted file %s (version=%d cipher=%s)", path, doc.Racoon.Version, doc.Racoon.Cipher)
+	}
+	if doc.Values == nil {
+		doc.Values = make(map[string]string)
+	}
+	return doc, nil
+}
+
+// writeEncryptedFile replaces the file by renaming a temporary file, leaving the file intact on failure
+func writeEncryptedFile(path string, doc *encryptedFileDocument) error {
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory for encrypted file %s, %v", path, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write encrypted file %s, %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(encryptedFileHeader + string(b)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write encrypted file %s, %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write encrypted file %s, %v", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write encrypted file %s, %v", path, err)
+	}
+	return nil
+}
+
+// encryptedFileAEAD creates the cipher using the base64 encoded 256 bit key set in the configured environment variable
+func encryptedFileAEAD(sourceConfig config.EncryptedFileConfig) (cipher.AEAD, error) {
+	env := encryptedFileDefaultKeyEnv
+	if len(sourceConfig.KeyEnv) > 0 {
+		env = sourceConfig.KeyEnv
+	}
+
+	encoded := strings.TrimSpace(environment.StringVar(env, ""))
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("%s key not set, set environment variable %s", config.SourceTypeEncryptedFile, env)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid %s key in environment variable %s, must be 32 random bytes encoded as base64", config.SourceTypeEncryptedFile, env)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NOTE: Really ugly hack to avoid magic strings, poor performance expected
+func missingEncryptedFilePathError() error {
+	m := config.Manifest{}
+	p := config.PropertyConfig{
+		Source: &config.ValueSourceConfig{
+			EncryptedFile: &config.ValueFromEncryptedFile{},
+		},
+	}
+	configKey := strings.Join(tagsForFields(&m, &m.Config, &m.Config.Sources, &m.Config.Sources.EncryptedFile, &m.Config.Sources.EncryptedFile.Path), ".")
+	sourceKey := strings.Join(tagsForFields(&p, &p.Source, &p.Source.EncryptedFile, &p.Source.EncryptedFile.Path), ".")
+	return fmt.Errorf("path missing for %s, set %s or %s", api.SourceTypeEncryptedFile, configKey, sourceKey)
+}
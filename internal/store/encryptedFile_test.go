@@ -0,0 +1,123 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	testEncryptedFileKey  = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	otherEncryptedFileKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+var _ = Describe("EncryptedFile", func() {
+	var ctx config.AppContext
+	var layer api.Layer
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "secrets.{context}.yaml")
+		os.Setenv("RACOON_ENCRYPTED_FILE_KEY", testEncryptedFileKey)
+
+		ctx = config.AppContext{
+			Context:  context.Background(),
+			Log:      logrus.New(),
+			Metadata: config.AppMetadata{Version: "test"},
+			Manifest: config.Manifest{
+				MetadataConfig: config.MetadataConfig{Name: "myapp"},
+				Config: config.Config{
+					Sources: config.SourceConfig{
+						EncryptedFile: config.EncryptedFileConfig{
+							Path: path,
+						},
+					},
+				},
+			},
+			Parameters: config.OrderedParameterList{{Key: "context", Value: "dev"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("RACOON_ENCRYPTED_FILE_KEY")
+		os.Unsetenv("OTHER_KEY")
+	})
+
+	read := func(vs *store.ValueStore, key string, source config.ValueFromEncryptedFile) api.Value {
+		return vs.Read(layer, key, false, &config.ValueSourceConfig{EncryptedFile: &source}, layer.Config)
+	}
+
+	It("returns not found error for missing file", func() {
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "Database.Password", config.ValueFromEncryptedFile{})
+		Expect(api.IsNotFoundError(val.Error())).To(BeTrue())
+		Expect(val.Key()).To(Equal(ctx.Replace(path) + "#database/password"))
+	})
+
+	It("writes encrypted values and reads them back", func() {
+		vs := store.NewValueStore(ctx)
+		key := ctx.Replace(path) + "#database/password"
+		Expect(vs.Write(key, "s3cr3t", "Database password", api.SourceTypeEncryptedFile, config.SourceConfig{})).To(Succeed())
+
+		b, err := os.ReadFile(ctx.Replace(path))
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(string(b)).To(ContainSubstring("database/password"))
+		Expect(string(b)).ToNot(ContainSubstring("s3cr3t"))
+
+		val := read(vs, "Database.Password", config.ValueFromEncryptedFile{})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("s3cr3t"))
+	})
+
+	It("keeps other values when writing and deleting", func() {
+		vs := store.NewValueStore(ctx)
+		file := ctx.Replace(path)
+		Expect(vs.Write(file+"#a", "1", "", api.SourceTypeEncryptedFile, config.SourceConfig{})).To(Succeed())
+		Expect(vs.Write(file+"#b", "2", "", api.SourceTypeEncryptedFile, config.SourceConfig{})).To(Succeed())
+		Expect(vs.Delete(file+"#a", api.SourceTypeEncryptedFile, config.SourceConfig{})).To(Succeed())
+
+		_, found, err := vs.ReadKey(file+"#a", api.SourceTypeEncryptedFile, config.SourceConfig{})
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(found).To(BeFalse())
+
+		value, found, err := vs.ReadKey(file+"#b", api.SourceTypeEncryptedFile, config.SourceConfig{})
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal("2"))
+	})
+
+	It("returns error when decrypting using another key", func() {
+		vs := store.NewValueStore(ctx)
+		key := ctx.Replace(path) + "#api_key"
+		Expect(vs.Write(key, "key", "", api.SourceTypeEncryptedFile, config.SourceConfig{})).To(Succeed())
+
+		os.Setenv("OTHER_KEY", otherEncryptedFileKey)
+		_, _, err := vs.ReadKey(key, api.SourceTypeEncryptedFile, config.SourceConfig{EncryptedFile: config.EncryptedFileConfig{KeyEnv: "OTHER_KEY"}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns error when the key is not set", func() {
+		vs := store.NewValueStore(ctx)
+		os.Unsetenv("RACOON_ENCRYPTED_FILE_KEY")
+		err := vs.Write(ctx.Replace(path)+"#api_key", "key", "", api.SourceTypeEncryptedFile, config.SourceConfig{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("RACOON_ENCRYPTED_FILE_KEY"))
+	})
+
+	It("returns error when path is not configured", func() {
+		ctx.Manifest.Config.Sources.EncryptedFile.Path = ""
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "ApiKey", config.ValueFromEncryptedFile{})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("config.sources.encryptedFile.path"))
+	})
+})
@@ -3,6 +3,7 @@ package store
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/dotnetmentor/racoon/internal/api"
 	"github.com/dotnetmentor/racoon/internal/config"
@@ -17,10 +18,15 @@ func newEnvironment() (*Environment, error) {
 }
 
 type Environment struct {
+	mu             sync.Mutex
 	dotfilesLoaded []string
 }
 
 func (s *Environment) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromEnvironment, sourceConfig config.EnvConfig) api.Value {
+	// dotfiles are loaded into the process environment, reads must not interleave with loading
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for _, dff := range sourceConfig.Dotfiles {
 		df := ctx.Replace(dff)
 		if utils.StringSliceContains(s.dotfilesLoaded, df) {
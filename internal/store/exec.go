@@ -0,0 +1,116 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+)
+
+const (
+	execDefaultTimeout = 30 * time.Second
+	execKeyEnv         = "RACOON_KEY"
+)
+
+func newExec() (*Exec, error) {
+	return &Exec{}, nil
+}
+
+// Exec reads values from the output of external commands, an escape hatch for secret backends not supported natively.
+// A command printing nothing is treated as the value not being found, a command exiting with a non-zero code as an error.
+type Exec struct{}
+
+func (s *Exec) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromExec, sourceConfig config.ExecConfig) api.Value {
+	sensitive = sensitive || sourceConfig.ForceSensitive
+
+	if len(propertySource.Command) == 0 {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), "", "", missingExecCommandError(), sensitive)
+	}
+
+	command := ctx.Replace(propertySource.Command)
+	args := make([]string, 0, len(propertySource.Args))
+	for _, a := range propertySource.Args {
+		args = append(args, ctx.Replace(a))
+	}
+	ek := strings.TrimSpace(strings.Join(append([]string{command}, args...), " "))
+
+	t := sourceConfig.Timeout
+	if len(propertySource.Timeout) > 0 {
+		t = propertySource.Timeout
+	}
+	timeout, err := commandTimeout(config.SourceTypeExec, t)
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), ek, "", err, sensitive)
+	}
+
+	ctx.Log.Debugf("reading %s from %s", ek, config.SourceTypeExec)
+
+	c := ctx.Context
+	if c == nil {
+		c = context.Background()
+	}
+	c, cancel := context.WithTimeout(c, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(c, command, args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", execKeyEnv, key))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(c.Err(), context.DeadlineExceeded) {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), ek, "", fmt.Errorf("command %s timed out after %s", command, timeout), sensitive)
+		}
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), ek, "", fmt.Errorf("command %s failed, %v: %s", command, err, strings.TrimSpace(stderr.String())), sensitive)
+	}
+
+	value := stdout.String()
+	if propertySource.Trim == nil || *propertySource.Trim {
+		value = strings.TrimSpace(value)
+	}
+
+	if len(value) == 0 {
+		treatAsError := sourceConfig.TreatNotFoundAsError
+		if propertySource.TreatNotFoundAsError != nil {
+			treatAsError = *propertySource.TreatNotFoundAsError
+		}
+		if treatAsError {
+			ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", ek, config.SourceTypeExec)
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), ek, "", fmt.Errorf("%s not found in %s, configured to be treated as an error", ek, config.SourceTypeExec), sensitive)
+		}
+		ctx.Log.Debugf("%s not found in %s", ek, config.SourceTypeExec)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), ek, "", api.NewNotFoundError(nil, ek, api.SourceTypeExec), sensitive)
+	}
+
+	return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), ek, value, nil, sensitive)
+}
+
+// commandTimeout parses the timeout of commands run by a source, using the default timeout when not set
+func commandTimeout(sourceType config.SourceType, timeout string) (time.Duration, error) {
+	if len(timeout) == 0 {
+		return execDefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s timeout %s, must be a positive duration (example: 10s)", sourceType, timeout)
+	}
+	return d, nil
+}
+
+// NOTE: Really ugly hack to avoid magic strings, poor performance expected
+func missingExecCommandError() error {
+	p := config.PropertyConfig{
+		Source: &config.ValueSourceConfig{
+			Exec: &config.ValueFromExec{},
+		},
+	}
+	sourceKey := strings.Join(tagsForFields(&p, &p.Source, &p.Source.Exec, &p.Source.Exec.Command), ".")
+	return fmt.Errorf("command missing for %s, set %s", api.SourceTypeExec, sourceKey)
+}
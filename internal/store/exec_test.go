@@ -0,0 +1,99 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exec", func() {
+	var ctx config.AppContext
+	var layer api.Layer
+	var script string
+
+	BeforeEach(func() {
+		script = filepath.Join(GinkgoT().TempDir(), "secret.sh")
+		Expect(os.WriteFile(script, []byte(`#!/bin/sh
+case "$1" in
+  echo) echo "  $2  " ;;
+  key) echo "$RACOON_KEY" ;;
+  empty) ;;
+  fail) echo "access denied" >&2; exit 3 ;;
+  sleep) exec sleep 5 ;;
+esac
+`), 0755)).To(Succeed())
+
+		ctx = config.AppContext{
+			Context:    context.Background(),
+			Log:        logrus.New(),
+			Metadata:   config.AppMetadata{Version: "test"},
+			Manifest:   config.Manifest{MetadataConfig: config.MetadataConfig{Name: "myapp"}},
+			Parameters: config.OrderedParameterList{{Key: "context", Value: "dev"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	read := func(key string, source config.ValueFromExec) api.Value {
+		vs := store.NewValueStore(ctx)
+		return vs.Read(layer, key, false, &config.ValueSourceConfig{Exec: &source}, layer.Config)
+	}
+
+	It("reads trimmed output using replaced args", func() {
+		val := read("ApiKey", config.ValueFromExec{Command: script, Args: []string{"echo", "{context}-key"}})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("dev-key"))
+		Expect(val.Key()).To(Equal(script + " echo dev-key"))
+	})
+
+	It("keeps output as is when trimming is disabled", func() {
+		trim := false
+		val := read("ApiKey", config.ValueFromExec{Command: script, Args: []string{"echo", "key"}, Trim: &trim})
+		Expect(val.Raw()).To(Equal("  key  \n"))
+	})
+
+	It("passes the property name to the command", func() {
+		Expect(read("Database.Password", config.ValueFromExec{Command: script, Args: []string{"key"}}).Raw()).To(Equal("Database.Password"))
+	})
+
+	It("returns not found error when the command prints nothing", func() {
+		Expect(api.IsNotFoundError(read("ApiKey", config.ValueFromExec{Command: script, Args: []string{"empty"}}).Error())).To(BeTrue())
+
+		ctx.Manifest.Config.Sources.Exec.TreatNotFoundAsError = true
+		val := read("ApiKey", config.ValueFromExec{Command: script, Args: []string{"empty"}})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+	})
+
+	It("returns error including stderr when the command fails", func() {
+		val := read("ApiKey", config.ValueFromExec{Command: script, Args: []string{"fail"}})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("access denied"))
+	})
+
+	It("returns error when the command times out", func() {
+		val := read("ApiKey", config.ValueFromExec{Command: script, Args: []string{"sleep"}, Timeout: "100ms"})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("timed out after 100ms"))
+	})
+
+	It("returns error for invalid timeouts", func() {
+		ctx.Manifest.Config.Sources.Exec.Timeout = "soon"
+		val := read("ApiKey", config.ValueFromExec{Command: script, Args: []string{"echo", "key"}})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("invalid exec timeout"))
+	})
+
+	It("returns error when command is not configured", func() {
+		val := read("ApiKey", config.ValueFromExec{})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("source.exec.command"))
+	})
+})
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+
+	"gopkg.in/yaml.v2"
+)
+
+func newFile() (*File, error) {
+	return &File{
+		documents: make(map[string]interface{}),
+	}, nil
+}
+
+// File reads values from local files, either the whole contents of a file or a value queried from a json or yaml document
+type File struct {
+	mu        sync.Mutex
+	documents map[string]interface{}
+}
+
+func (s *File) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromFile, sourceConfig config.FileConfig) api.Value {
+	sensitive = sensitive || sourceConfig.ForceSensitive
+
+	if len(propertySource.Path) == 0 {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeFile), "", "", missingFilePathError(), sensitive)
+	}
+	path := awpParameterStoreKey(ctx.Replace(propertySource.Path), key)
+	query := ctx.Replace(propertySource.Query)
+
+	fk := joinKeyField(path, query)
+	ctx.Log.Debugf("reading %s from %s", fk, config.SourceTypeFile)
+
+	var value string
+	var found bool
+	var err error
+	if len(query) == 0 {
+		value, found, err = s.readFile(path)
+	} else {
+		value, found, err = s.readQuery(path, query)
+	}
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeFile), fk, "", err, sensitive)
+	}
+
+	if !found {
+		treatAsError := sourceConfig.TreatNotFoundAsError
+		if propertySource.TreatNotFoundAsError != nil {
+			treatAsError = *propertySource.TreatNotFoundAsError
+		}
+		if treatAsError {
+			ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", fk, config.SourceTypeFile)
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeFile), fk, "", fmt.Errorf("%s not found in %s, configured to be treated as an error", fk, config.SourceTypeFile), sensitive)
+		}
+		ctx.Log.Debugf("%s not found in %s", fk, config.SourceTypeFile)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeFile), fk, "", api.NewNotFoundError(nil, fk, api.SourceTypeFile), sensitive)
+	}
+
+	return api.NewValue(api.NewValueSource(layer, api.SourceTypeFile), fk, value, nil, sensitive)
+}
+
+func (s *File) readFile(path string) (string, bool, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read file %s, %v", path, err)
+	}
+	return string(b), true, nil
+}
+
+func (s *File) readQuery(path, query string) (string, bool, error) {
+	doc, found, err := s.document(path)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	v, found := lookupPath(doc, query)
+	if !found {
+		return "", false, nil
+	}
+
+	value, err := documentValue(v)
+	return value, err == nil, err
+}
+
+// document returns the parsed json or yaml document of a file, documents are parsed once and kept in memory
+func (s *File) document(path string) (interface{}, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if doc, ok := s.documents[path]; ok {
+		return doc, doc != nil, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		s.documents[path] = nil
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read file %s, %v", path, err)
+	}
+
+	var doc interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+		err = d.Decode(&doc)
+	default:
+		// json is valid yaml, any other file is parsed as yaml
+		err = yaml.Unmarshal(b, &doc)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse file %s, %v", path, err)
+	}
+
+	s.documents[path] = doc
+	return doc, true, nil
+}
+
+// NOTE: Really ugly hack to avoid magic strings, poor performance expected
+func missingFilePathError() error {
+	p := config.PropertyConfig{
+		Source: &config.ValueSourceConfig{
+			File: &config.ValueFromFile{},
+		},
+	}
+	sourceKey := strings.Join(tagsForFields(&p, &p.Source, &p.Source.File, &p.Source.File.Path), ".")
+	return fmt.Errorf("path missing for %s, set %s", api.SourceTypeFile, sourceKey)
+}
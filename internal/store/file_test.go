@@ -0,0 +1,91 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File", func() {
+	var ctx config.AppContext
+	var layer api.Layer
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "certs", "dev"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "certs", "dev", "tls.crt"), []byte("-----BEGIN CERTIFICATE-----\ndev\n-----END CERTIFICATE-----\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "secrets.json"), []byte(`{"Database":{"Password":"s3cr3t","Port":5432}}`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "secrets.yaml"), []byte("Database:\n  Password: yamls3cr3t\n  Hosts: [a, b]\n  Options:\n    ssl: true\n"), 0644)).To(Succeed())
+
+		ctx = config.AppContext{
+			Context:    context.Background(),
+			Log:        logrus.New(),
+			Metadata:   config.AppMetadata{Version: "test"},
+			Manifest:   config.Manifest{MetadataConfig: config.MetadataConfig{Name: "myapp"}},
+			Parameters: config.OrderedParameterList{{Key: "context", Value: "dev"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	read := func(key string, source config.ValueFromFile) api.Value {
+		vs := store.NewValueStore(ctx)
+		return vs.Read(layer, key, false, &config.ValueSourceConfig{File: &source}, layer.Config)
+	}
+
+	It("reads the whole file", func() {
+		val := read("Certificate", config.ValueFromFile{Path: filepath.Join(dir, "certs", "{context}", "tls.crt")})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("-----BEGIN CERTIFICATE-----\ndev\n-----END CERTIFICATE-----\n"))
+		Expect(val.Key()).To(Equal(filepath.Join(dir, "certs", "dev", "tls.crt")))
+	})
+
+	It("reads values from json documents", func() {
+		val := read("Password", config.ValueFromFile{Path: filepath.Join(dir, "secrets.json"), Query: "Database.Password"})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("s3cr3t"))
+		Expect(val.Key()).To(Equal(filepath.Join(dir, "secrets.json") + "#Database.Password"))
+
+		Expect(read("Port", config.ValueFromFile{Path: filepath.Join(dir, "secrets.json"), Query: "Database.Port"}).Raw()).To(Equal("5432"))
+	})
+
+	It("reads values from yaml documents", func() {
+		path := filepath.Join(dir, "secrets.yaml")
+		Expect(read("Password", config.ValueFromFile{Path: path, Query: "Database.Password"}).Raw()).To(Equal("yamls3cr3t"))
+		Expect(read("Host", config.ValueFromFile{Path: path, Query: "Database.Hosts.0"}).Raw()).To(Equal("a"))
+		Expect(read("Options", config.ValueFromFile{Path: path, Query: "Database.Options"}).Raw()).To(Equal(`{"ssl":true}`))
+	})
+
+	It("returns not found error for missing files and values", func() {
+		Expect(api.IsNotFoundError(read("Certificate", config.ValueFromFile{Path: filepath.Join(dir, "missing.crt")}).Error())).To(BeTrue())
+		Expect(api.IsNotFoundError(read("User", config.ValueFromFile{Path: filepath.Join(dir, "secrets.json"), Query: "Database.User"}).Error())).To(BeTrue())
+	})
+
+	It("returns error when configured to treat not found as error", func() {
+		ctx.Manifest.Config.Sources.File.TreatNotFoundAsError = true
+		val := read("Certificate", config.ValueFromFile{Path: filepath.Join(dir, "missing.crt")})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+	})
+
+	It("returns error for documents that can not be parsed", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "invalid.json"), []byte(`{"Database":`), 0644)).To(Succeed())
+		val := read("Password", config.ValueFromFile{Path: filepath.Join(dir, "invalid.json"), Query: "Database.Password"})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+	})
+
+	It("returns error when path is not configured", func() {
+		val := read("Certificate", config.ValueFromFile{})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("source.file.path"))
+	})
+})
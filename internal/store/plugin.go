@@ -0,0 +1,177 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+)
+
+const (
+	PluginProtocolVersion = 1
+
+	PluginOperationRead    = "read"
+	PluginOperationReadKey = "readKey"
+	PluginOperationWrite   = "write"
+	PluginOperationDelete  = "delete"
+)
+
+func newPlugin() (*Plugin, error) {
+	return &Plugin{}, nil
+}
+
+// Plugin reads and writes values using external source plugins. A plugin is a command started for every operation,
+// receiving a json request on stdin and replying with a json response on stdout.
+type Plugin struct{}
+
+// PluginRequest is written to the stdin of a plugin
+type PluginRequest struct {
+	Version     int                    `json:"version"`
+	Operation   string                 `json:"operation"`
+	Property    string                 `json:"property,omitempty"`
+	Key         string                 `json:"key,omitempty"`
+	Value       string                 `json:"value,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Config      map[string]interface{} `json:"config,omitempty"`
+	Parameters  map[string]string      `json:"parameters,omitempty"`
+}
+
+// PluginResponse is read from the stdout of a plugin, key is the key a value was read from (read)
+// and found tells if the value exists (read, readKey)
+type PluginResponse struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value,omitempty"`
+	Found bool   `json:"found,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (s *Plugin) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromPlugin, pluginConfig config.PluginConfig) api.Value {
+	sourceType := api.PluginSourceType(propertySource.Name)
+	sensitive = sensitive || pluginConfig.ForceSensitive
+
+	res, err := s.call(ctx, propertySource.Name, pluginConfig, PluginRequest{
+		Operation: PluginOperationRead,
+		Property:  key,
+		Key:       ctx.Replace(propertySource.Key),
+	})
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, sourceType), propertySource.Key, "", err, sensitive)
+	}
+
+	pk := res.Key
+	if len(pk) == 0 {
+		pk = key
+	}
+
+	if !res.Found {
+		treatAsError := pluginConfig.TreatNotFoundAsError
+		if propertySource.TreatNotFoundAsError != nil {
+			treatAsError = *propertySource.TreatNotFoundAsError
+		}
+		if treatAsError {
+			ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", pk, sourceType)
+			return api.NewValue(api.NewValueSource(layer, sourceType), pk, "", fmt.Errorf("%s not found in %s, configured to be treated as an error", pk, sourceType), sensitive)
+		}
+		ctx.Log.Debugf("%s not found in %s", pk, sourceType)
+		return api.NewValue(api.NewValueSource(layer, sourceType), pk, "", api.NewNotFoundError(nil, pk, sourceType), sensitive)
+	}
+
+	return api.NewValue(api.NewValueSource(layer, sourceType), pk, res.Value, nil, sensitive)
+}
+
+func (s *Plugin) Write(ctx config.AppContext, name, key, value, description string, pluginConfig config.PluginConfig) error {
+	ctx.Log.Infof("upserting value %s in %s", key, api.PluginSourceType(name))
+	_, err := s.call(ctx, name, pluginConfig, PluginRequest{
+		Operation:   PluginOperationWrite,
+		Key:         key,
+		Value:       value,
+		Description: description,
+	})
+	return err
+}
+
+func (s *Plugin) ReadKey(ctx config.AppContext, name, key string, pluginConfig config.PluginConfig) (string, bool, error) {
+	res, err := s.call(ctx, name, pluginConfig, PluginRequest{
+		Operation: PluginOperationReadKey,
+		Key:       key,
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return res.Value, res.Found, nil
+}
+
+func (s *Plugin) Delete(ctx config.AppContext, name, key string, pluginConfig config.PluginConfig) error {
+	ctx.Log.Infof("deleting value %s in %s", key, api.PluginSourceType(name))
+	_, err := s.call(ctx, name, pluginConfig, PluginRequest{
+		Operation: PluginOperationDelete,
+		Key:       key,
+	})
+	return err
+}
+
+func (s *Plugin) call(ctx config.AppContext, name string, pluginConfig config.PluginConfig, req PluginRequest) (PluginResponse, error) {
+	var res PluginResponse
+
+	if len(pluginConfig.Command) == 0 {
+		return res, fmt.Errorf("plugin %s has no command, set config.sources.plugins.%s.command", name, name)
+	}
+
+	timeout, err := commandTimeout(config.SourceType(api.PluginSourceType(name)), pluginConfig.Timeout)
+	if err != nil {
+		return res, err
+	}
+
+	req.Version = PluginProtocolVersion
+	req.Config = jsonCompatible(pluginConfig.Config).(map[string]interface{})
+	req.Parameters = make(map[string]string)
+	for _, p := range ctx.Parameters {
+		req.Parameters[p.Key] = p.Value
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return res, err
+	}
+
+	c := ctx.Context
+	if c == nil {
+		c = context.Background()
+	}
+	c, cancel := context.WithTimeout(c, timeout)
+	defer cancel()
+
+	command := ctx.Replace(pluginConfig.Command)
+	args := make([]string, 0, len(pluginConfig.Args))
+	for _, a := range pluginConfig.Args {
+		args = append(args, ctx.Replace(a))
+	}
+
+	ctx.Log.Debugf("calling plugin %s (%s), operation %s", name, command, req.Operation)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(c, command, args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(c.Err(), context.DeadlineExceeded) {
+			return res, fmt.Errorf("plugin %s timed out after %s", name, timeout)
+		}
+		return res, fmt.Errorf("plugin %s failed, %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		return res, fmt.Errorf("plugin %s returned an invalid response, %v", name, err)
+	}
+	if len(res.Error) > 0 {
+		return res, fmt.Errorf("plugin %s failed to %s, %s", name, req.Operation, res.Error)
+	}
+	return res, nil
+}
@@ -0,0 +1,161 @@
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testPluginEnv = "RACOON_TEST_PLUGIN"
+
+// the test binary acts as a plugin when started by a test, storing values in a json file set in the plugin config
+func init() {
+	if os.Getenv(testPluginEnv) != "1" {
+		return
+	}
+
+	var req store.PluginRequest
+	var res store.PluginResponse
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		os.Stderr.WriteString(err.Error())
+		os.Exit(1)
+	}
+
+	file, _ := req.Config["file"].(string)
+	values := map[string]string{}
+	if b, err := os.ReadFile(file); err == nil {
+		_ = json.Unmarshal(b, &values)
+	}
+
+	switch req.Operation {
+	case store.PluginOperationRead:
+		res.Key = req.Key
+		if len(res.Key) == 0 {
+			res.Key = req.Parameters["context"] + "/" + strings.ToLower(req.Property)
+		}
+		res.Value, res.Found = values[res.Key]
+	case store.PluginOperationReadKey:
+		res.Value, res.Found = values[req.Key]
+	case store.PluginOperationWrite:
+		if req.Config["readOnly"] == true {
+			res.Error = "writes are not supported"
+			break
+		}
+		values[req.Key] = req.Value
+	case store.PluginOperationDelete:
+		delete(values, req.Key)
+	}
+
+	b, _ := json.Marshal(values)
+	_ = os.WriteFile(file, b, 0644)
+	_ = json.NewEncoder(os.Stdout).Encode(res)
+	os.Exit(0)
+}
+
+var _ = Describe("Plugin", func() {
+	var ctx config.AppContext
+	var layer api.Layer
+	var file string
+
+	BeforeEach(func() {
+		os.Setenv(testPluginEnv, "1")
+		file = filepath.Join(GinkgoT().TempDir(), "values.json")
+		Expect(os.WriteFile(file, []byte(`{"dev/apikey":"key","shared/password":"s3cr3t"}`), 0644)).To(Succeed())
+
+		ctx = config.AppContext{
+			Context:  context.Background(),
+			Log:      logrus.New(),
+			Metadata: config.AppMetadata{Version: "test"},
+			Manifest: config.Manifest{
+				MetadataConfig: config.MetadataConfig{Name: "myapp"},
+				Config: config.Config{
+					Sources: config.SourceConfig{
+						Plugins: map[string]config.PluginConfig{
+							"test": {
+								Command: os.Args[0],
+								Config:  map[string]interface{}{"file": file},
+							},
+						},
+					},
+				},
+			},
+			Parameters: config.OrderedParameterList{{Key: "context", Value: "dev"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv(testPluginEnv)
+	})
+
+	read := func(vs *store.ValueStore, key string, source config.ValueFromPlugin) api.Value {
+		return vs.Read(layer, key, false, &config.ValueSourceConfig{Plugin: &source}, layer.Config)
+	}
+
+	It("reads values using the key returned by the plugin", func() {
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "ApiKey", config.ValueFromPlugin{Name: "test"})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("key"))
+		Expect(val.Key()).To(Equal("dev/apikey"))
+		Expect(val.Source().Type()).To(Equal(api.PluginSourceType("test")))
+		Expect(val.Source().Writable()).To(BeTrue())
+
+		Expect(read(vs, "Password", config.ValueFromPlugin{Name: "test", Key: "shared/password"}).Raw()).To(Equal("s3cr3t"))
+	})
+
+	It("returns not found error when the plugin does not find the value", func() {
+		vs := store.NewValueStore(ctx)
+		Expect(api.IsNotFoundError(read(vs, "Password", config.ValueFromPlugin{Name: "test"}).Error())).To(BeTrue())
+	})
+
+	It("writes, reads and deletes values", func() {
+		vs := store.NewValueStore(ctx)
+		sourceType := api.PluginSourceType("test")
+		Expect(vs.Write("dev/password", "p4ss", "", sourceType, config.SourceConfig{})).To(Succeed())
+
+		value, found, err := vs.ReadKey("dev/password", sourceType, config.SourceConfig{})
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal("p4ss"))
+
+		Expect(vs.Delete("dev/password", sourceType, config.SourceConfig{})).To(Succeed())
+		_, found, err = vs.ReadKey("dev/password", sourceType, config.SourceConfig{})
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(found).To(BeFalse())
+	})
+
+	It("merges plugin config of layers", func() {
+		vs := store.NewValueStore(ctx)
+		sc := config.SourceConfig{Plugins: map[string]config.PluginConfig{"test": {Config: map[string]interface{}{"readOnly": true}}}}
+		err := vs.Write("dev/password", "p4ss", "", api.PluginSourceType("test"), sc)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("writes are not supported"))
+	})
+
+	It("returns error for undeclared plugins", func() {
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "ApiKey", config.ValueFromPlugin{Name: "other"})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("plugin other not declared"))
+	})
+
+	It("returns error when the plugin fails", func() {
+		os.Unsetenv(testPluginEnv)
+		ctx.Manifest.Config.Sources.Plugins["test"] = config.PluginConfig{Command: "false"}
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "ApiKey", config.ValueFromPlugin{Name: "test"})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("plugin test failed"))
+	})
+})
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/environment"
+)
+
+const (
+	sopsDefaultKey    = "{key}"
+	sopsDefaultBinary = "sops"
+	sopsBinaryEnv     = "RACOON_SOPS_BINARY"
+)
+
+func newSops() (*Sops, error) {
+	return &Sops{
+		files: make(map[string]interface{}),
+	}, nil
+}
+
+// Sops reads values from files encrypted using SOPS. Files are decrypted by the sops binary, using the keys
+// (age, PGP, KMS) available to it, and kept decrypted in memory for the lifetime of the store.
+type Sops struct {
+	mu    sync.Mutex
+	files map[string]interface{}
+}
+
+func (s *Sops) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromSops, sourceConfig config.SopsConfig) api.Value {
+	sensitive = sensitive || sourceConfig.ForceSensitive
+
+	path := sourceConfig.Path
+	if len(propertySource.Path) > 0 {
+		path = propertySource.Path
+	}
+	if len(path) == 0 {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeSops), "", "", missingSopsPathError(), sensitive)
+	}
+	// keys in paths are separated like directories, keys in documents like properties
+	path = awpParameterStoreKey(ctx.Replace(path), key)
+
+	skf := sopsDefaultKey
+	if len(sourceConfig.DefaultKey) > 0 {
+		skf = sourceConfig.DefaultKey
+	}
+	if len(propertySource.Key) > 0 {
+		skf = propertySource.Key
+	}
+
+	sk := joinKeyField(path, sopsKey(ctx.Replace(skf), key))
+	ctx.Log.Debugf("reading %s from %s", sk, config.SourceTypeSops)
+
+	doc, found, err := s.decrypt(ctx, path)
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeSops), sk, "", err, sensitive)
+	}
+
+	var v interface{}
+	if found {
+		_, field := splitKeyField(sk)
+		v, found = lookupPath(doc, field)
+	}
+
+	if !found {
+		treatAsError := sourceConfig.TreatNotFoundAsError
+		if propertySource.TreatNotFoundAsError != nil {
+			treatAsError = *propertySource.TreatNotFoundAsError
+		}
+		if treatAsError {
+			ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", sk, config.SourceTypeSops)
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeSops), sk, "", fmt.Errorf("%s not found in %s, configured to be treated as an error", sk, config.SourceTypeSops), sensitive)
+		}
+		ctx.Log.Debugf("%s not found in %s", sk, config.SourceTypeSops)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeSops), sk, "", api.NewNotFoundError(nil, sk, api.SourceTypeSops), sensitive)
+	}
+
+	value, err := documentValue(v)
+	return api.NewValue(api.NewValueSource(layer, api.SourceTypeSops), sk, value, err, sensitive)
+}
+
+// decrypt returns the decrypted document of a file, or false when the file does not exist
+func (s *Sops) decrypt(ctx config.AppContext, path string) (interface{}, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if doc, ok := s.files[path]; ok {
+		return doc, doc != nil, nil
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		ctx.Log.Debugf("file %s not found in %s", path, config.SourceTypeSops)
+		s.files[path] = nil
+		return nil, false, nil
+	}
+
+	c := ctx.Context
+	if c == nil {
+		c = context.Background()
+	}
+
+	binary := environment.StringVar(sopsBinaryEnv, sopsDefaultBinary)
+	ctx.Log.Debugf("decrypting %s using %s", path, binary)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(c, binary, "--decrypt", "--output-type", "json", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, false, fmt.Errorf("failed to decrypt %s using %s, %v: %s", path, binary, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var doc interface{}
+	d := json.NewDecoder(&stdout)
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse decrypted %s, %v", path, err)
+	}
+
+	s.files[path] = doc
+	return doc, true, nil
+}
+
+// sopsKey replaces {key} using the property name unchanged, matching documents keyed by property name (e.g. Database.Password)
+func sopsKey(format, key string) string {
+	return strings.ReplaceAll(format, "{key}", key)
+}
+
+// NOTE: Really ugly hack to avoid magic strings, poor performance expected
+func missingSopsPathError() error {
+	m := config.Manifest{}
+	p := config.PropertyConfig{
+		Source: &config.ValueSourceConfig{
+			Sops: &config.ValueFromSops{},
+		},
+	}
+	configKey := strings.Join(tagsForFields(&m, &m.Config, &m.Config.Sources, &m.Config.Sources.Sops, &m.Config.Sources.Sops.Path), ".")
+	sourceKey := strings.Join(tagsForFields(&p, &p.Source, &p.Source.Sops, &p.Source.Sops.Path), ".")
+	return fmt.Errorf("path missing for %s, set %s or %s", api.SourceTypeSops, configKey, sourceKey)
+}
@@ -0,0 +1,121 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sops", func() {
+	var ctx config.AppContext
+	var layer api.Layer
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+
+		// fake sops, printing the file as already decrypted json
+		binary := filepath.Join(dir, "sops")
+		Expect(os.WriteFile(binary, []byte("#!/bin/sh\ncat \"$4\"\n"), 0755)).To(Succeed())
+		os.Setenv("RACOON_SOPS_BINARY", binary)
+
+		Expect(os.WriteFile(filepath.Join(dir, "secrets.dev.json"), []byte(`{"Database":{"Password":"s3cr3t","Port":5432},"hosts":["a","b"],"ApiKey":"key"}`), 0644)).To(Succeed())
+
+		ctx = config.AppContext{
+			Context:  context.Background(),
+			Log:      logrus.New(),
+			Metadata: config.AppMetadata{Version: "test"},
+			Manifest: config.Manifest{
+				MetadataConfig: config.MetadataConfig{Name: "myapp"},
+				Config: config.Config{
+					Sources: config.SourceConfig{
+						Sops: config.SopsConfig{
+							Path: filepath.Join(dir, "secrets.{context}.json"),
+						},
+					},
+				},
+			},
+			Parameters: config.OrderedParameterList{{Key: "context", Value: "dev"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("RACOON_SOPS_BINARY")
+	})
+
+	read := func(vs *store.ValueStore, key string, source config.ValueFromSops) api.Value {
+		return vs.Read(layer, key, false, &config.ValueSourceConfig{Sops: &source}, layer.Config)
+	}
+
+	It("reads values using the property name as path", func() {
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "Database.Password", config.ValueFromSops{})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("s3cr3t"))
+		Expect(val.Key()).To(Equal(filepath.Join(dir, "secrets.dev.json") + "#Database.Password"))
+	})
+
+	It("reads values using a configured key", func() {
+		vs := store.NewValueStore(ctx)
+		Expect(read(vs, "Port", config.ValueFromSops{Key: "Database.Port"}).Raw()).To(Equal("5432"))
+		Expect(read(vs, "Host", config.ValueFromSops{Key: "hosts.1"}).Raw()).To(Equal("b"))
+		Expect(read(vs, "Hosts", config.ValueFromSops{Key: "hosts"}).Raw()).To(Equal(`["a","b"]`))
+	})
+
+	It("reads values using a default key", func() {
+		ctx.Manifest.Config.Sources.Sops.DefaultKey = "Database.{key}"
+		vs := store.NewValueStore(ctx)
+		Expect(read(vs, "Port", config.ValueFromSops{}).Raw()).To(Equal("5432"))
+	})
+
+	It("replaces key in path", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "api_key.json"), []byte(`{"value":"other"}`), 0644)).To(Succeed())
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "ApiKey", config.ValueFromSops{Path: filepath.Join(dir, "{key}.json"), Key: "value"})
+		Expect(val.Error()).To(Not(HaveOccurred()))
+		Expect(val.Raw()).To(Equal("other"))
+	})
+
+	It("returns not found error for missing keys and files", func() {
+		vs := store.NewValueStore(ctx)
+		Expect(api.IsNotFoundError(read(vs, "Database.User", config.ValueFromSops{}).Error())).To(BeTrue())
+		Expect(api.IsNotFoundError(read(vs, "Hosts", config.ValueFromSops{Key: "hosts.2"}).Error())).To(BeTrue())
+		Expect(api.IsNotFoundError(read(vs, "ApiKey", config.ValueFromSops{Path: filepath.Join(dir, "missing.json")}).Error())).To(BeTrue())
+	})
+
+	It("returns error when configured to treat not found as error", func() {
+		vs := store.NewValueStore(ctx)
+		treatAsError := true
+		val := read(vs, "Database.User", config.ValueFromSops{TreatNotFoundAsError: &treatAsError})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+	})
+
+	It("returns error when decryption fails", func() {
+		binary := filepath.Join(dir, "failing-sops")
+		Expect(os.WriteFile(binary, []byte("#!/bin/sh\necho 'no matching key' >&2\nexit 128\n"), 0755)).To(Succeed())
+		os.Setenv("RACOON_SOPS_BINARY", binary)
+
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "ApiKey", config.ValueFromSops{})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("no matching key"))
+	})
+
+	It("returns error when path is not configured", func() {
+		ctx.Manifest.Config.Sources.Sops.Path = ""
+		vs := store.NewValueStore(ctx)
+		val := read(vs, "ApiKey", config.ValueFromSops{})
+		Expect(val.Error()).To(HaveOccurred())
+		Expect(val.Error().Error()).To(ContainSubstring("config.sources.sops.path"))
+	})
+})
@@ -1,7 +1,11 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/dotnetmentor/racoon/internal/api"
 	"github.com/dotnetmentor/racoon/internal/config"
@@ -15,9 +19,17 @@ func NewValueStore(ctx config.AppContext) *ValueStore {
 
 type ValueStore struct {
 	context config.AppContext
+	mu      sync.Mutex
 
 	awsParameterStore *AwsParameterStore
+	awsSecretsManager *AwsSecretsManager
 	environment       *Environment
+	vault             *Vault
+	encryptedFile     *EncryptedFile
+	sops              *Sops
+	file              *File
+	exec              *Exec
+	plugin            *Plugin
 }
 
 func (vs *ValueStore) Read(layer api.Layer, key string, sensitive bool, source *config.ValueSourceConfig, sourceConfig config.SourceConfig) api.Value {
@@ -38,32 +50,129 @@ func (vs *ValueStore) Read(layer api.Layer, key string, sensitive bool, source *
 		return api.NewValue(api.NewValueSource(layer, api.SourceTypeLiteral), key, *source.Literal, nil, sensitive)
 
 	case config.SourceTypeEnvironment:
-		if vs.environment == nil {
-			store, err := newEnvironment()
-			if err != nil {
-				return api.NewValue(api.NewValueSource(layer, api.SourceTypeEnvironment), "", "", err, sensitive)
-			}
-			vs.environment = store
+		store, err := vs.environmentStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeEnvironment), "", "", err, sensitive)
 		}
 
-		return vs.environment.Read(vs.context, layer, key, sensitive, *source.Environment, sourceConfig.Env)
+		return store.Read(vs.context, layer, key, sensitive, *source.Environment, sourceConfig.Env)
 
 	case config.SourceTypeAwsParameterStore:
 		mc := m.Config.Sources.AwsParameterStore.Merge(sourceConfig.AwsParameterStore)
-		if vs.awsParameterStore == nil {
-			store, err := newAwsParameterStore(vs.context.Context)
-			if err != nil {
-				return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), "", "", err, sensitive || mc.ForceSensitive)
-			}
-			vs.awsParameterStore = store
+		store, err := vs.parameterStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsParameterStore), "", "", err, sensitive || mc.ForceSensitive)
+		}
+
+		return store.Read(vs.context, layer, key, sensitive, *source.AwsParameterStore, mc)
+
+	case config.SourceTypeAwsSecretsManager:
+		mc := m.Config.Sources.AwsSecretsManager.Merge(sourceConfig.AwsSecretsManager)
+		store, err := vs.secretsManager()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeAwsSecretsManager), "", "", err, sensitive || mc.ForceSensitive)
+		}
+
+		return store.Read(vs.context, layer, key, sensitive, *source.AwsSecretsManager, mc)
+
+	case config.SourceTypeVault:
+		mc := m.Config.Sources.Vault.Merge(sourceConfig.Vault)
+		store, err := vs.vaultStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeVault), "", "", err, sensitive || mc.ForceSensitive)
+		}
+
+		return store.Read(vs.context, layer, key, sensitive, *source.Vault, mc)
+
+	case config.SourceTypeEncryptedFile:
+		mc := m.Config.Sources.EncryptedFile.Merge(sourceConfig.EncryptedFile)
+		store, err := vs.encryptedFileStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeEncryptedFile), "", "", err, sensitive || mc.ForceSensitive)
 		}
 
-		return vs.awsParameterStore.Read(vs.context, layer, key, sensitive, *source.AwsParameterStore, mc)
+		return store.Read(vs.context, layer, key, sensitive, *source.EncryptedFile, mc)
+
+	case config.SourceTypeSops:
+		mc := m.Config.Sources.Sops.Merge(sourceConfig.Sops)
+		store, err := vs.sopsStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeSops), "", "", err, sensitive || mc.ForceSensitive)
+		}
+
+		return store.Read(vs.context, layer, key, sensitive, *source.Sops, mc)
+
+	case config.SourceTypeFile:
+		mc := m.Config.Sources.File.Merge(sourceConfig.File)
+		store, err := vs.fileStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeFile), "", "", err, sensitive || mc.ForceSensitive)
+		}
+
+		return store.Read(vs.context, layer, key, sensitive, *source.File, mc)
+
+	case config.SourceTypeExec:
+		mc := m.Config.Sources.Exec.Merge(sourceConfig.Exec)
+		store, err := vs.execStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeExec), "", "", err, sensitive || mc.ForceSensitive)
+		}
+
+		return store.Read(vs.context, layer, key, sensitive, *source.Exec, mc)
+
+	case config.SourceTypePlugin:
+		sourceType := api.PluginSourceType(source.Plugin.Name)
+		pc, err := vs.pluginConfig(source.Plugin.Name, sourceConfig)
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, sourceType), "", "", err, sensitive)
+		}
+		store, err := vs.pluginStore()
+		if err != nil {
+			return api.NewValue(api.NewValueSource(layer, sourceType), "", "", err, sensitive || pc.ForceSensitive)
+		}
+
+		return store.Read(vs.context, layer, key, sensitive, *source.Plugin, pc)
 	}
 
 	return nil
 }
 
+// ReadRequest describes a value that will be read from a source
+type ReadRequest struct {
+	Key          string
+	Source       *config.ValueSourceConfig
+	SourceConfig config.SourceConfig
+}
+
+// Prefetch reads values ahead of time for sources supporting batched reads, errors are logged
+// and left for Read to report
+func (vs *ValueStore) Prefetch(requests []ReadRequest) {
+	m := vs.context.Manifest
+
+	psKeys := make([]string, 0)
+	for _, r := range requests {
+		switch r.Source.SourceType() {
+		case config.SourceTypeAwsParameterStore:
+			mc := m.Config.Sources.AwsParameterStore.Merge(r.SourceConfig.AwsParameterStore)
+			if psk, err := parameterStoreKey(vs.context, r.Key, *r.Source.AwsParameterStore, mc); err == nil {
+				psKeys = append(psKeys, psk)
+			}
+		}
+	}
+
+	if len(psKeys) > 0 {
+		store, err := vs.parameterStore()
+		if err != nil {
+			vs.context.Log.Debugf("skipping prefetch from %s, %v", config.SourceTypeAwsParameterStore, err)
+			return
+		}
+
+		if err := store.Prefetch(vs.context, psKeys); err != nil {
+			vs.context.Log.Warnf("failed to prefetch values from %s, %v", config.SourceTypeAwsParameterStore, err)
+		}
+	}
+}
+
 func (vs *ValueStore) Write(key, value, description string, sourceType api.SourceType, sourceConfig config.SourceConfig) error {
 	if !sourceType.Writable() {
 		return fmt.Errorf("unsupported source type %s, source is not writable", sourceType)
@@ -71,11 +180,418 @@ func (vs *ValueStore) Write(key, value, description string, sourceType api.Sourc
 
 	m := vs.context.Manifest
 
+	if name, ok := sourceType.Plugin(); ok {
+		pc, err := vs.pluginConfig(name, sourceConfig)
+		if err != nil {
+			return err
+		}
+		store, err := vs.pluginStore()
+		if err != nil {
+			return err
+		}
+		return store.Write(vs.context, name, key, value, description, pc)
+	}
+
 	switch sourceType {
 	case api.SourceTypeAwsParameterStore:
 		mc := m.Config.Sources.AwsParameterStore.Merge(sourceConfig.AwsParameterStore)
-		return vs.awsParameterStore.Write(vs.context, key, value, description, mc)
+		store, err := vs.parameterStore()
+		if err != nil {
+			return err
+		}
+		return store.Write(vs.context, key, value, description, mc)
+
+	case api.SourceTypeAwsSecretsManager:
+		mc := m.Config.Sources.AwsSecretsManager.Merge(sourceConfig.AwsSecretsManager)
+		store, err := vs.secretsManager()
+		if err != nil {
+			return err
+		}
+		return store.Write(vs.context, key, value, description, mc)
+
+	case api.SourceTypeVault:
+		mc := m.Config.Sources.Vault.Merge(sourceConfig.Vault)
+		store, err := vs.vaultStore()
+		if err != nil {
+			return err
+		}
+		return store.Write(vs.context, key, value, description, mc)
+
+	case api.SourceTypeEncryptedFile:
+		mc := m.Config.Sources.EncryptedFile.Merge(sourceConfig.EncryptedFile)
+		store, err := vs.encryptedFileStore()
+		if err != nil {
+			return err
+		}
+		return store.Write(vs.context, key, value, description, mc)
 	}
 
 	return nil
 }
+
+// ReadKey reads the current value of a source key from a writable source, bypassing any cached values
+func (vs *ValueStore) ReadKey(key string, sourceType api.SourceType, sourceConfig config.SourceConfig) (value string, found bool, err error) {
+	if !sourceType.Writable() {
+		return "", false, fmt.Errorf("unsupported source type %s, source is not writable", sourceType)
+	}
+
+	m := vs.context.Manifest
+
+	if name, ok := sourceType.Plugin(); ok {
+		pc, err := vs.pluginConfig(name, sourceConfig)
+		if err != nil {
+			return "", false, err
+		}
+		store, err := vs.pluginStore()
+		if err != nil {
+			return "", false, err
+		}
+		return store.ReadKey(vs.context, name, key, pc)
+	}
+
+	switch sourceType {
+	case api.SourceTypeAwsParameterStore:
+		store, err := vs.parameterStore()
+		if err != nil {
+			return "", false, err
+		}
+		return store.ReadKey(vs.context, key)
+
+	case api.SourceTypeAwsSecretsManager:
+		store, err := vs.secretsManager()
+		if err != nil {
+			return "", false, err
+		}
+		return store.ReadKey(vs.context, key)
+
+	case api.SourceTypeVault:
+		mc := m.Config.Sources.Vault.Merge(sourceConfig.Vault)
+		store, err := vs.vaultStore()
+		if err != nil {
+			return "", false, err
+		}
+		return store.ReadKey(vs.context, key, mc)
+
+	case api.SourceTypeEncryptedFile:
+		mc := m.Config.Sources.EncryptedFile.Merge(sourceConfig.EncryptedFile)
+		store, err := vs.encryptedFileStore()
+		if err != nil {
+			return "", false, err
+		}
+		return store.ReadKey(vs.context, key, mc)
+	}
+
+	return "", false, nil
+}
+
+func (vs *ValueStore) Delete(key string, sourceType api.SourceType, sourceConfig config.SourceConfig) error {
+	if !sourceType.Writable() {
+		return fmt.Errorf("unsupported source type %s, source is not writable", sourceType)
+	}
+
+	m := vs.context.Manifest
+
+	if name, ok := sourceType.Plugin(); ok {
+		pc, err := vs.pluginConfig(name, sourceConfig)
+		if err != nil {
+			return err
+		}
+		store, err := vs.pluginStore()
+		if err != nil {
+			return err
+		}
+		return store.Delete(vs.context, name, key, pc)
+	}
+
+	switch sourceType {
+	case api.SourceTypeAwsParameterStore:
+		store, err := vs.parameterStore()
+		if err != nil {
+			return err
+		}
+		return store.Delete(vs.context, key)
+
+	case api.SourceTypeAwsSecretsManager:
+		store, err := vs.secretsManager()
+		if err != nil {
+			return err
+		}
+		return store.Delete(vs.context, key)
+
+	case api.SourceTypeVault:
+		mc := m.Config.Sources.Vault.Merge(sourceConfig.Vault)
+		store, err := vs.vaultStore()
+		if err != nil {
+			return err
+		}
+		return store.Delete(vs.context, key, mc)
+
+	case api.SourceTypeEncryptedFile:
+		store, err := vs.encryptedFileStore()
+		if err != nil {
+			return err
+		}
+		return store.Delete(vs.context, key)
+	}
+
+	return nil
+}
+
+// Copy writes the value read from one writable source to another and verifies the written value by reading it back
+func (vs *ValueStore) Copy(from, to api.Value, description string) error {
+	if from.Error() != nil {
+		return fmt.Errorf("unable to copy from %s, %v", from.SourceAndKey(), from.Error())
+	}
+
+	if err := vs.Write(to.Key(), from.Raw(), description, to.Source().Type(), to.Source().Layer().Config); err != nil {
+		return err
+	}
+
+	value, found, err := vs.ReadKey(to.Key(), to.Source().Type(), to.Source().Layer().Config)
+	if err != nil {
+		return fmt.Errorf("failed to verify copy to %s, %v", to.SourceAndKey(), err)
+	}
+	if !found || value != from.Raw() {
+		return fmt.Errorf("failed to verify copy to %s, value read back does not match", to.SourceAndKey())
+	}
+
+	vs.context.Log.Debugf("verified copy from %s to %s", from.SourceAndKey(), to.SourceAndKey())
+	return nil
+}
+
+// Stores are created on first use, guarded by a lock as reads may happen concurrently
+
+func (vs *ValueStore) environmentStore() (*Environment, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.environment == nil {
+		store, err := newEnvironment()
+		if err != nil {
+			return nil, err
+		}
+		vs.environment = store
+	}
+	return vs.environment, nil
+}
+
+func (vs *ValueStore) parameterStore() (*AwsParameterStore, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.awsParameterStore == nil {
+		store, err := newAwsParameterStore(vs.context.Context)
+		if err != nil {
+			return nil, err
+		}
+		vs.awsParameterStore = store
+	}
+	return vs.awsParameterStore, nil
+}
+
+func (vs *ValueStore) secretsManager() (*AwsSecretsManager, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.awsSecretsManager == nil {
+		store, err := newAwsSecretsManager(vs.context.Context)
+		if err != nil {
+			return nil, err
+		}
+		vs.awsSecretsManager = store
+	}
+	return vs.awsSecretsManager, nil
+}
+
+func (vs *ValueStore) vaultStore() (*Vault, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.vault == nil {
+		store, err := newVault()
+		if err != nil {
+			return nil, err
+		}
+		vs.vault = store
+	}
+	return vs.vault, nil
+}
+
+func (vs *ValueStore) encryptedFileStore() (*EncryptedFile, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.encryptedFile == nil {
+		store, err := newEncryptedFile()
+		if err != nil {
+			return nil, err
+		}
+		vs.encryptedFile = store
+	}
+	return vs.encryptedFile, nil
+}
+
+func (vs *ValueStore) sopsStore() (*Sops, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.sops == nil {
+		store, err := newSops()
+		if err != nil {
+			return nil, err
+		}
+		vs.sops = store
+	}
+	return vs.sops, nil
+}
+
+func (vs *ValueStore) fileStore() (*File, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.file == nil {
+		store, err := newFile()
+		if err != nil {
+			return nil, err
+		}
+		vs.file = store
+	}
+	return vs.file, nil
+}
+
+func (vs *ValueStore) execStore() (*Exec, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.exec == nil {
+		store, err := newExec()
+		if err != nil {
+			return nil, err
+		}
+		vs.exec = store
+	}
+	return vs.exec, nil
+}
+
+func (vs *ValueStore) pluginStore() (*Plugin, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.plugin == nil {
+		store, err := newPlugin()
+		if err != nil {
+			return nil, err
+		}
+		vs.plugin = store
+	}
+	return vs.plugin, nil
+}
+
+// pluginConfig returns the configuration of a plugin merged with the configuration of a layer
+func (vs *ValueStore) pluginConfig(name string, sourceConfig config.SourceConfig) (config.PluginConfig, error) {
+	if len(name) == 0 {
+		return config.PluginConfig{}, fmt.Errorf("plugin name missing, set source.plugin.name")
+	}
+	pc, ok := vs.context.Manifest.Config.Sources.Plugin(name, sourceConfig)
+	if !ok {
+		return pc, fmt.Errorf("plugin %s not declared, declare it in config.sources.plugins", name)
+	}
+	return pc, nil
+}
+
+type resourceTag struct {
+	key   string
+	value string
+}
+
+func resourceTags(ctx config.AppContext) (tags []resourceTag) {
+	if ctx.Manifest.Name != "" {
+		tags = append(tags, resourceTag{key: "racoon/owner", value: ctx.Manifest.Name})
+	}
+
+	tags = append(tags, resourceTag{key: "racoon/version", value: ctx.Metadata.Version})
+
+	for k, v := range ctx.Manifest.Labels {
+		tags = append(tags, resourceTag{key: k, value: ctx.Replace(v)})
+	}
+	return
+}
+
+func missingKeyError(sourceType api.SourceType, configKey, sourceKey string) error {
+	return fmt.Errorf("key missing for %s, set %s or %s", sourceType, configKey, sourceKey)
+}
+
+// keyFieldSeparator separates a source key from the field selected within it,
+// '#' is not allowed in secret names or parameter names
+const keyFieldSeparator = "#"
+
+func joinKeyField(key, field string) string {
+	if len(field) == 0 {
+		return key
+	}
+	return key + keyFieldSeparator + field
+}
+
+func splitKeyField(key string) (string, string) {
+	parts := strings.SplitN(key, keyFieldSeparator, 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return key, ""
+}
+
+// lookupPath traverses a decoded json or yaml document using a dotted path, array elements are selected by index
+func lookupPath(doc interface{}, path string) (interface{}, bool) {
+	v := doc
+	for _, p := range strings.Split(path, ".") {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			next, ok := t[p]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case map[interface{}]interface{}:
+			next, ok := t[p]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, false
+			}
+			v = t[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, v != nil
+}
+
+// documentValue returns strings as is and any other value of a document as json
+func documentValue(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(jsonCompatible(v))
+	return string(b), err
+}
+
+// jsonCompatible converts maps decoded from yaml to maps that can be encoded as json
+func jsonCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprint(k)] = jsonCompatible(v)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = jsonCompatible(v)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = jsonCompatible(v)
+		}
+		return l
+	default:
+		return v
+	}
+}
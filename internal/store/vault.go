@@ -0,0 +1,392 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/environment"
+)
+
+const (
+	vaultDefaultMount   = "secret"
+	vaultDefaultField   = "value"
+	vaultApproleMount   = "approle"
+	vaultRequestTimeout = 30 * time.Second
+)
+
+func newVault() (*Vault, error) {
+	return &Vault{
+		client: &http.Client{Timeout: vaultRequestTimeout},
+		tokens: make(map[string]string),
+	}, nil
+}
+
+// Vault reads and writes secrets from a HashiCorp Vault KV v2 secrets engine
+type Vault struct {
+	client *http.Client
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+type vaultSecret struct {
+	Data     map[string]interface{} `json:"data"`
+	Metadata struct {
+		Version int `json:"version"`
+	} `json:"metadata"`
+}
+
+func (s *Vault) Read(ctx config.AppContext, layer api.Layer, key string, sensitive bool, propertySource config.ValueFromVault, sourceConfig config.VaultConfig) api.Value {
+	sensitive = sensitive || sourceConfig.ForceSensitive
+
+	vkf := sourceConfig.DefaultKey
+	if len(propertySource.Key) > 0 {
+		vkf = propertySource.Key
+	}
+
+	if len(vkf) == 0 {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeVault), "", "", missingVaultKeyError(), sensitive)
+	}
+
+	field := propertySource.Field
+	if len(field) == 0 {
+		field = vaultDefaultField
+	}
+
+	path := strings.Trim(awpParameterStoreKey(ctx.Replace(vkf), key), "/")
+	vk := joinKeyField(path, field)
+	ctx.Log.Debugf("reading %s from %s", vk, config.SourceTypeVault)
+
+	secret, err := s.readSecret(ctx, sourceConfig, path)
+	if err != nil {
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeVault), vk, "", err, sensitive)
+	}
+
+	var fv interface{}
+	found := false
+	if secret != nil {
+		fv, found = secret.Data[field]
+	}
+
+	if !found {
+		treatAsError := sourceConfig.TreatNotFoundAsError
+		if propertySource.TreatNotFoundAsError != nil {
+			treatAsError = *propertySource.TreatNotFoundAsError
+		}
+		if treatAsError {
+			ctx.Log.Warnf("%s not found in %s, configured to be treated as an error", vk, config.SourceTypeVault)
+			return api.NewValue(api.NewValueSource(layer, api.SourceTypeVault), vk, "", fmt.Errorf("%s not found in %s, configured to be treated as an error", vk, config.SourceTypeVault), sensitive)
+		}
+		ctx.Log.Debugf("%s not found in %s", vk, config.SourceTypeVault)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeVault), vk, "", api.NewNotFoundError(nil, vk, api.SourceTypeVault), sensitive)
+	}
+
+	switch v := fv.(type) {
+	case string:
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeVault), vk, v, nil, sensitive)
+	default:
+		b, err := json.Marshal(v)
+		return api.NewValue(api.NewValueSource(layer, api.SourceTypeVault), vk, string(b), err, sensitive)
+	}
+}
+
+func (s *Vault) Write(ctx config.AppContext, key, value, description string, sourceConfig config.VaultConfig) error {
+	path, field := splitKeyField(key)
+	if len(field) == 0 {
+		field = vaultDefaultField
+	}
+
+	ctx.Log.Infof("upserting secret %s in %s", key, api.SourceTypeVault)
+
+	secret, err := s.readSecret(ctx, sourceConfig, path)
+	if err != nil {
+		ctx.Log.Errorf("failed to read secret %s in %s, %v", path, config.SourceTypeVault, err)
+		return err
+	}
+
+	data := make(map[string]interface{})
+	version := 0
+	if secret != nil {
+		if secret.Data != nil {
+			data = secret.Data
+		}
+		version = secret.Metadata.Version
+	} else {
+		// the latest version may be deleted while the secret still exists, check-and-set requires its version
+		version, err = s.currentVersion(ctx, sourceConfig, path)
+		if err != nil {
+			ctx.Log.Errorf("failed to read metadata of secret %s in %s, %v", path, config.SourceTypeVault, err)
+			return err
+		}
+	}
+	data[field] = value
+
+	// check-and-set guards against overwriting changes made since the secret was read
+	body := map[string]interface{}{
+		"options": map[string]interface{}{"cas": version},
+		"data":    data,
+	}
+	if _, err := s.request(ctx, sourceConfig, http.MethodPost, fmt.Sprintf("%s/data/%s", vaultMount(sourceConfig), path), body, nil); err != nil {
+		ctx.Log.Errorf("failed to write secret %s in %s, %v", path, config.SourceTypeVault, err)
+		return err
+	}
+
+	metadata := make(map[string]string)
+	for _, t := range resourceTags(ctx) {
+		metadata[t.key] = t.value
+	}
+	if len(description) > 0 {
+		metadata["racoon/description"] = description
+	}
+	if _, err := s.request(ctx, sourceConfig, http.MethodPost, fmt.Sprintf("%s/metadata/%s", vaultMount(sourceConfig), path), map[string]interface{}{"custom_metadata": metadata}, nil); err != nil {
+		ctx.Log.Errorf("failed to tag secret %s in %s, %v", path, config.SourceTypeVault, err)
+		return err
+	}
+
+	return nil
+}
+
+// ReadKey reads a field of a secret by its source key
+func (s *Vault) ReadKey(ctx config.AppContext, key string, sourceConfig config.VaultConfig) (string, bool, error) {
+	path, field := splitKeyField(key)
+	if len(field) == 0 {
+		field = vaultDefaultField
+	}
+
+	secret, err := s.readSecret(ctx, sourceConfig, path)
+	if err != nil || secret == nil {
+		return "", false, err
+	}
+
+	fv, ok := secret.Data[field]
+	if !ok {
+		return "", false, nil
+	}
+	if v, ok := fv.(string); ok {
+		return v, true, nil
+	}
+	b, err := json.Marshal(fv)
+	return string(b), true, err
+}
+
+// Delete removes a field from a secret by writing a new version, the latest version of the secret is deleted
+// when its last field is removed. Previous versions are kept and can be restored using vault.
+func (s *Vault) Delete(ctx config.AppContext, key string, sourceConfig config.VaultConfig) error {
+	path, field := splitKeyField(key)
+	if len(field) == 0 {
+		field = vaultDefaultField
+	}
+
+	secret, err := s.readSecret(ctx, sourceConfig, path)
+	if err != nil {
+		ctx.Log.Errorf("failed to read secret %s in %s, %v", path, config.SourceTypeVault, err)
+		return err
+	}
+	if secret == nil || secret.Data == nil {
+		ctx.Log.Debugf("secret %s not found in %s, nothing to delete", path, config.SourceTypeVault)
+		return nil
+	}
+	if _, ok := secret.Data[field]; !ok {
+		ctx.Log.Debugf("field %s not found in secret %s, nothing to delete", field, path)
+		return nil
+	}
+	delete(secret.Data, field)
+
+	if len(secret.Data) > 0 {
+		ctx.Log.Infof("removing field %s from secret %s in %s", field, path, api.SourceTypeVault)
+		body := map[string]interface{}{
+			"options": map[string]interface{}{"cas": secret.Metadata.Version},
+			"data":    secret.Data,
+		}
+		if _, err := s.request(ctx, sourceConfig, http.MethodPost, fmt.Sprintf("%s/data/%s", vaultMount(sourceConfig), path), body, nil); err != nil {
+			ctx.Log.Errorf("failed to write secret %s in %s, %v", path, config.SourceTypeVault, err)
+			return err
+		}
+		return nil
+	}
+
+	ctx.Log.Infof("deleting secret %s in %s", path, api.SourceTypeVault)
+	if _, err := s.request(ctx, sourceConfig, http.MethodDelete, fmt.Sprintf("%s/data/%s", vaultMount(sourceConfig), path), nil, nil); err != nil {
+		ctx.Log.Errorf("failed to delete secret %s in %s, %v", path, config.SourceTypeVault, err)
+		return err
+	}
+	return nil
+}
+
+// readSecret returns the latest version of a secret, or nil when the secret does not exist
+func (s *Vault) readSecret(ctx config.AppContext, sourceConfig config.VaultConfig, path string) (*vaultSecret, error) {
+	res := struct {
+		Data *vaultSecret `json:"data"`
+	}{}
+	status, err := s.request(ctx, sourceConfig, http.MethodGet, fmt.Sprintf("%s/data/%s", vaultMount(sourceConfig), path), nil, &res)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// currentVersion returns the current version of a secret from its metadata, or 0 when the secret does not exist.
+// The current version is set even when the latest version of the secret is deleted.
+func (s *Vault) currentVersion(ctx config.AppContext, sourceConfig config.VaultConfig, path string) (int, error) {
+	res := struct {
+		Data struct {
+			CurrentVersion int `json:"current_version"`
+		} `json:"data"`
+	}{}
+	status, err := s.request(ctx, sourceConfig, http.MethodGet, fmt.Sprintf("%s/metadata/%s", vaultMount(sourceConfig), path), nil, &res)
+	if status == http.StatusNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.Data.CurrentVersion, nil
+}
+
+func (s *Vault) request(ctx config.AppContext, sourceConfig config.VaultConfig, method, path string, body, out interface{}) (int, error) {
+	address, err := vaultAddress(sourceConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	token, err := s.token(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.do(ctx, address, token, method, path, body, out)
+}
+
+func (s *Vault) do(ctx config.AppContext, address, token, method, path string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context, method, fmt.Sprintf("%s/v1/%s", address, path), reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) > 0 {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ns := environment.StringVar("VAULT_NAMESPACE", ""); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		errs := struct {
+			Errors []string `json:"errors"`
+		}{}
+		json.Unmarshal(b, &errs)
+		return res.StatusCode, fmt.Errorf("vault request %s %s failed with status %d, errors: %v", method, path, res.StatusCode, errs.Errors)
+	}
+
+	if out != nil && len(b) > 0 {
+		if err := json.Unmarshal(b, out); err != nil {
+			return res.StatusCode, fmt.Errorf("failed to parse vault response for %s %s, %v", method, path, err)
+		}
+	}
+
+	return res.StatusCode, nil
+}
+
+// token resolves a vault token using VAULT_TOKEN or, when not set, an AppRole login using VAULT_ROLE_ID and VAULT_SECRET_ID
+func (s *Vault) token(ctx config.AppContext, address string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.tokens[address]; ok {
+		return t, nil
+	}
+
+	if t := environment.StringVar("VAULT_TOKEN", ""); t != "" {
+		s.tokens[address] = t
+		return t, nil
+	}
+
+	roleID := environment.StringVar("VAULT_ROLE_ID", "")
+	secretID := environment.StringVar("VAULT_SECRET_ID", "")
+	if roleID == "" || secretID == "" {
+		return "", fmt.Errorf("vault authentication not configured, set VAULT_TOKEN or VAULT_ROLE_ID and VAULT_SECRET_ID")
+	}
+
+	mount := environment.StringVar("VAULT_APPROLE_MOUNT", vaultApproleMount)
+	ctx.Log.Debugf("authenticating with %s using approle (mount=%s)", config.SourceTypeVault, mount)
+
+	res := struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}{}
+	if _, err := s.do(ctx, address, "", http.MethodPost, fmt.Sprintf("auth/%s/login", mount), map[string]string{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	}, &res); err != nil {
+		return "", fmt.Errorf("vault approle login failed, %v", err)
+	}
+
+	if res.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault approle login returned no client token")
+	}
+
+	s.tokens[address] = res.Auth.ClientToken
+	return res.Auth.ClientToken, nil
+}
+
+func vaultAddress(sourceConfig config.VaultConfig) (string, error) {
+	address := sourceConfig.Address
+	if len(address) == 0 {
+		address = environment.StringVar("VAULT_ADDR", "")
+	}
+	if len(address) == 0 {
+		return "", fmt.Errorf("vault address not set, set %s address or environment variable VAULT_ADDR", config.SourceTypeVault)
+	}
+	return strings.TrimSuffix(address, "/"), nil
+}
+
+func vaultMount(sourceConfig config.VaultConfig) string {
+	if len(sourceConfig.Mount) > 0 {
+		return strings.Trim(sourceConfig.Mount, "/")
+	}
+	return vaultDefaultMount
+}
+
+// NOTE: Really ugly hack to avoid magic strings, poor performance expected
+func missingVaultKeyError() error {
+	m := config.Manifest{}
+	p := config.PropertyConfig{
+		Source: &config.ValueSourceConfig{
+			Vault: &config.ValueFromVault{},
+		},
+	}
+	configKey := strings.Join(tagsForFields(&m, &m.Config, &m.Config.Sources, &m.Config.Sources.Vault, &m.Config.Sources.Vault.DefaultKey), ".")
+	sourceKey := strings.Join(tagsForFields(&p, &p.Source, &p.Source.Vault, &p.Source.Vault.Key), ".")
+	return missingKeyError(api.SourceTypeVault, configKey, sourceKey)
+}
@@ -0,0 +1,339 @@
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeVault struct {
+	mu       sync.Mutex
+	token    string
+	secrets  map[string]map[string]interface{}
+	versions map[string]int
+	deleted  map[string]bool
+	metadata map[string]map[string]string
+}
+
+func newFakeVault(token string) *fakeVault {
+	return &fakeVault{
+		token:    token,
+		secrets:  make(map[string]map[string]interface{}),
+		versions: make(map[string]int),
+		deleted:  make(map[string]bool),
+		metadata: make(map[string]map[string]string),
+	}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]string{"client_token": f.token}})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := f.secrets[path]
+			if !ok || f.deleted[path] {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"data":     data,
+					"metadata": map[string]interface{}{"version": f.versions[path]},
+				},
+			})
+		case http.MethodPost:
+			body := struct {
+				Options map[string]int         `json:"options"`
+				Data    map[string]interface{} `json:"data"`
+			}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if cas, ok := body.Options["cas"]; ok && cas != f.versions[path] {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			f.secrets[path] = body.Data
+			f.versions[path]++
+			f.deleted[path] = false
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": f.versions[path]}})
+		case http.MethodDelete:
+			// soft deletes the latest version, the metadata and current version are kept
+			f.deleted[path] = true
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/")
+		if r.Method == http.MethodGet {
+			if _, ok := f.versions[path]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"current_version": f.versions[path]}})
+			return
+		}
+		body := struct {
+			CustomMetadata map[string]string `json:"custom_metadata"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.metadata[path] = body.CustomMetadata
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("Vault", func() {
+	var server *httptest.Server
+	var vault *fakeVault
+	var ctx config.AppContext
+	var layer api.Layer
+
+	BeforeEach(func() {
+		vault = newFakeVault("test-token")
+		vault.secrets["myapp/dev/database"] = map[string]interface{}{"password": "s3cr3t", "port": 5432}
+		vault.versions["myapp/dev/database"] = 1
+		server = httptest.NewServer(vault)
+
+		os.Unsetenv("VAULT_ADDR")
+		os.Setenv("VAULT_TOKEN", "test-token")
+
+		ctx = config.AppContext{
+			Context:  context.Background(),
+			Log:      logrus.New(),
+			Metadata: config.AppMetadata{Version: "test"},
+			Manifest: config.Manifest{
+				MetadataConfig: config.MetadataConfig{Name: "myapp"},
+				Config: config.Config{
+					Sources: config.SourceConfig{
+						Vault: config.VaultConfig{
+							Address:    server.URL,
+							DefaultKey: "{name}/{context}/{key}",
+						},
+					},
+				},
+			},
+			Parameters: config.OrderedParameterList{{Key: "context", Value: "dev"}},
+		}
+		layer, _ = api.NewLayer("base", []config.SourceType{}, config.SourceConfig{}, true)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Unsetenv("VAULT_TOKEN")
+		os.Unsetenv("VAULT_ROLE_ID")
+		os.Unsetenv("VAULT_SECRET_ID")
+	})
+
+	read := func(key string, source config.ValueFromVault) api.Value {
+		vs := store.NewValueStore(ctx)
+		return vs.Read(layer, key, true, &config.ValueSourceConfig{Vault: &source}, layer.Config)
+	}
+
+	Describe("Read", func() {
+		It("reads the selected field using key and parameter substitution", func() {
+			val := read("Database", config.ValueFromVault{Field: "password"})
+			Expect(val.Error()).To(Not(HaveOccurred()))
+			Expect(val.Raw()).To(Equal("s3cr3t"))
+			Expect(val.Key()).To(Equal("myapp/dev/database#password"))
+			Expect(val.Sensitive()).To(BeTrue())
+		})
+
+		It("reads non string fields as json", func() {
+			val := read("Database", config.ValueFromVault{Field: "port"})
+			Expect(val.Error()).To(Not(HaveOccurred()))
+			Expect(val.Raw()).To(Equal("5432"))
+		})
+
+		It("returns not found error for missing secret", func() {
+			val := read("Missing", config.ValueFromVault{})
+			Expect(api.IsNotFoundError(val.Error())).To(BeTrue())
+			Expect(val.Key()).To(Equal("myapp/dev/missing#value"))
+		})
+
+		It("returns not found error for missing field", func() {
+			val := read("Database", config.ValueFromVault{Field: "username"})
+			Expect(api.IsNotFoundError(val.Error())).To(BeTrue())
+		})
+
+		It("returns error for missing field when configured to treat not found as error", func() {
+			treatAsError := true
+			val := read("Database", config.ValueFromVault{Field: "username", TreatNotFoundAsError: &treatAsError})
+			Expect(val.Error()).To(HaveOccurred())
+			Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+		})
+
+		It("authenticates using approle", func() {
+			os.Unsetenv("VAULT_TOKEN")
+			os.Setenv("VAULT_ROLE_ID", "role")
+			os.Setenv("VAULT_SECRET_ID", "secret")
+			val := read("Database", config.ValueFromVault{Field: "password"})
+			Expect(val.Error()).To(Not(HaveOccurred()))
+			Expect(val.Raw()).To(Equal("s3cr3t"))
+		})
+
+		It("returns error when not authenticated", func() {
+			os.Setenv("VAULT_TOKEN", "invalid")
+			val := read("Database", config.ValueFromVault{Field: "password"})
+			Expect(val.Error()).To(HaveOccurred())
+			Expect(api.IsNotFoundError(val.Error())).To(BeFalse())
+		})
+	})
+
+	Describe("Write", func() {
+		It("updates a single field and keeps the others", func() {
+			vs := store.NewValueStore(ctx)
+			err := vs.Write("myapp/dev/database#password", "n3w", "Database password", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(vault.secrets["myapp/dev/database"]["password"]).To(Equal("n3w"))
+			Expect(vault.secrets["myapp/dev/database"]).To(HaveKey("port"))
+			Expect(vault.versions["myapp/dev/database"]).To(Equal(2))
+		})
+
+		It("creates new secrets with racoon metadata", func() {
+			vs := store.NewValueStore(ctx)
+			err := vs.Write("myapp/dev/api_key#value", "key", "Api key", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(vault.secrets["myapp/dev/api_key"]["value"]).To(Equal("key"))
+			Expect(vault.metadata["myapp/dev/api_key"]).To(HaveKeyWithValue("racoon/owner", "myapp"))
+			Expect(vault.metadata["myapp/dev/api_key"]).To(HaveKeyWithValue("racoon/version", "test"))
+			Expect(vault.metadata["myapp/dev/api_key"]).To(HaveKeyWithValue("racoon/description", "Api key"))
+		})
+
+		It("writes secrets with a deleted latest version using the current version for check-and-set", func() {
+			vault.versions["myapp/dev/database"] = 3
+			vault.deleted["myapp/dev/database"] = true
+
+			vs := store.NewValueStore(ctx)
+			err := vs.Write("myapp/dev/database#password", "n3w", "Database password", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(vault.secrets["myapp/dev/database"]).To(Equal(map[string]interface{}{"password": "n3w"}))
+			Expect(vault.versions["myapp/dev/database"]).To(Equal(4))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes a single field and keeps the others", func() {
+			vs := store.NewValueStore(ctx)
+			err := vs.Delete("myapp/dev/database#password", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(vault.secrets["myapp/dev/database"]).ToNot(HaveKey("password"))
+			Expect(vault.secrets["myapp/dev/database"]).To(HaveKey("port"))
+		})
+
+		It("deletes the secret when removing the last field", func() {
+			vs := store.NewValueStore(ctx)
+			Expect(vs.Delete("myapp/dev/database#password", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+			Expect(vs.Delete("myapp/dev/database#port", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+			Expect(vault.deleted).To(HaveKeyWithValue("myapp/dev/database", true))
+
+			_, found, err := vs.ReadKey("myapp/dev/database#port", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found).To(BeFalse())
+		})
+
+		It("writes secrets again after deleting them", func() {
+			vs := store.NewValueStore(ctx)
+			Expect(vs.Delete("myapp/dev/database#password", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+			Expect(vs.Delete("myapp/dev/database#port", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+
+			Expect(vs.Write("myapp/dev/database#password", "n3w", "Database password", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+			value, found, err := vs.ReadKey("myapp/dev/database#password", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("n3w"))
+		})
+
+		It("ignores missing secrets", func() {
+			vs := store.NewValueStore(ctx)
+			Expect(vs.Delete("myapp/dev/missing#value", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+		})
+	})
+
+	Describe("Copy", func() {
+		It("writes and verifies the value", func() {
+			vs := store.NewValueStore(ctx)
+			from := read("Database", config.ValueFromVault{Field: "password"})
+			to := read("Password", config.ValueFromVault{})
+			Expect(vs.Copy(from, to, "Password")).To(Succeed())
+
+			value, found, err := vs.ReadKey("myapp/dev/password#value", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("s3cr3t"))
+		})
+
+		It("copies to secrets deleted before", func() {
+			vs := store.NewValueStore(ctx)
+			Expect(vs.Write("myapp/dev/password#value", "old", "Password", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+			Expect(vs.Delete("myapp/dev/password#value", api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+
+			from := read("Database", config.ValueFromVault{Field: "password"})
+			to := read("Password", config.ValueFromVault{})
+			Expect(vs.Copy(from, to, "Password")).To(Succeed())
+			Expect(vault.secrets["myapp/dev/password"]).To(Equal(map[string]interface{}{"value": "s3cr3t"}))
+		})
+
+		It("moves values back and forth", func() {
+			vs := store.NewValueStore(ctx)
+			move := func(from, to api.Value) {
+				Expect(vs.Copy(from, to, "Password")).To(Succeed())
+				Expect(vs.Delete(from.Key(), api.SourceTypeVault, config.SourceConfig{})).To(Succeed())
+			}
+
+			vault.secrets["myapp/dev/password"] = map[string]interface{}{"value": "s3cr3t"}
+			vault.versions["myapp/dev/password"] = 1
+
+			move(read("Password", config.ValueFromVault{}), read("Other", config.ValueFromVault{}))
+			Expect(vault.deleted).To(HaveKeyWithValue("myapp/dev/password", true))
+
+			move(read("Other", config.ValueFromVault{}), read("Password", config.ValueFromVault{}))
+			value, found, err := vs.ReadKey("myapp/dev/password#value", api.SourceTypeVault, config.SourceConfig{})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("s3cr3t"))
+			Expect(vault.deleted).To(HaveKeyWithValue("myapp/dev/other", true))
+		})
+
+		It("does not copy values read with errors", func() {
+			vs := store.NewValueStore(ctx)
+			from := read("Missing", config.ValueFromVault{})
+			to := read("Password", config.ValueFromVault{})
+			Expect(vs.Copy(from, to, "Password")).ToNot(Succeed())
+			Expect(vault.secrets).ToNot(HaveKey("myapp/dev/password"))
+		})
+	})
+})
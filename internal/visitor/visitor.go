@@ -2,6 +2,7 @@ package visitor
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/dotnetmentor/racoon/internal/api"
 	"github.com/dotnetmentor/racoon/internal/config"
@@ -15,6 +16,7 @@ func New(ctx config.AppContext) *Visitor {
 		store:      store.NewValueStore(ctx),
 		properties: make(api.PropertyList, 0),
 		layers:     make(api.LayerList, 0),
+		resolved:   make(map[readKey]api.Value),
 	}
 	return v
 }
@@ -24,6 +26,21 @@ type Visitor struct {
 	store      *store.ValueStore
 	properties api.PropertyList
 	layers     api.LayerList
+	resolved   map[readKey]api.Value
+}
+
+// readKey identifies a single read from a source within a layer, implicit reads are identified by source type
+type readKey struct {
+	layer    string
+	key      string
+	source   *config.ValueSourceConfig
+	implicit config.SourceType
+}
+
+type readRequest struct {
+	readKey
+	layer   api.Layer
+	request store.ReadRequest
 }
 
 func (vs *Visitor) Init(excludes, includes []string) error {
@@ -36,10 +53,6 @@ func (vs *Visitor) Init(excludes, includes []string) error {
 	if err != nil {
 		return err
 	}
-	explicit := vs.context.Manifest.Properties.Filter(excludes, includes)
-	vs.loadProperties(&base, implicit, explicit)
-	implicit = explicit.Merge(implicit)
-	vs.layers = append(vs.layers, base)
 
 	if len(vs.context.Manifest.Config.Parameters) > 0 {
 		vs.context.Log.Infof("matching layers with parameters (%s)", vs.context.Parameters.String())
@@ -49,12 +62,22 @@ func (vs *Visitor) Init(excludes, includes []string) error {
 		return err
 	}
 
+	layers := []api.Layer{base}
+	layerProperties := []config.PropertyList{vs.context.Manifest.Properties.Filter(excludes, includes)}
 	for _, l := range ls {
 		layer, err := api.NewLayer(l.Name, l.ImplicitSources, l.Config, false)
 		if err != nil {
 			return err
 		}
-		explicit := l.Properties.Filter(excludes, includes)
+		layers = append(layers, layer)
+		layerProperties = append(layerProperties, l.Properties.Filter(excludes, includes))
+	}
+
+	vs.resolve(layers, layerProperties)
+
+	for i := range layers {
+		layer := layers[i]
+		explicit := layerProperties[i]
 		vs.loadProperties(&layer, implicit, explicit)
 		implicit = explicit.Merge(implicit)
 		vs.layers = append(vs.layers, layer)
@@ -98,7 +121,7 @@ func (vs *Visitor) loadProperties(layer *api.Layer, implicit, explicit config.Pr
 
 	if len(layer.ImplicitSources) > 0 {
 		for _, p := range implicit.Remove(explicit) {
-			prop, _ := vs.newProperty(p.Name, p.Description, layer.Name, p.Sensitive, p.Rules, p.Format)
+			prop, _ := vs.newProperty(p.Name, p.Description, layer.Name, p.Sensitive, p.Type, p.Rules, p.Format)
 
 			if !prop.Rules().Override.AllowImplicit {
 				vs.context.Log.Debugf("skipping property %s, implicit overrides are not allowed by property rules", prop.Name)
@@ -108,25 +131,15 @@ func (vs *Visitor) loadProperties(layer *api.Layer, implicit, explicit config.Pr
 			for _, s := range layer.ImplicitSources {
 				vs.context.Log.Debugf("processing implicit property %s, reading from source %s", prop.Name, s)
 
-				var valueSource *config.ValueSourceConfig = nil
-				switch s {
-				case config.SourceTypeAwsParameterStore:
-					valueSource = &config.ValueSourceConfig{
-						AwsParameterStore: &config.ValueFromAwsParameterStore{},
-					}
-				case config.SourceTypeEnvironment:
-					valueSource = &config.ValueSourceConfig{
-						Environment: &config.ValueFromEnvironment{},
-					}
-				default:
+				valueSource := vs.implicitValueSource(s, *layer)
+				if valueSource == nil {
 					vs.context.Log.Warnf("unsupported implicit source %s", s)
+					continue
 				}
 
-				if valueSource != nil {
-					val := vs.store.Read(*layer, prop.Name, prop.Sensitive(), valueSource, layer.Config)
-					if val != nil {
-						prop.SetValue(val)
-					}
+				val := vs.read(readKey{layer: layer.Name, key: prop.Name, implicit: s}, *layer, prop.Sensitive(), valueSource)
+				if val != nil {
+					prop.SetValue(val)
 				}
 			}
 
@@ -135,7 +148,7 @@ func (vs *Visitor) loadProperties(layer *api.Layer, implicit, explicit config.Pr
 	}
 
 	for _, p := range explicit {
-		prop, ok := vs.newProperty(p.Name, p.Description, layer.Name, p.Sensitive, p.Rules, p.Format)
+		prop, ok := vs.newProperty(p.Name, p.Description, layer.Name, p.Sensitive, p.Type, p.Rules, p.Format)
 
 		if !layer.IsBaseLayer() && !prop.Rules().Override.AllowExplicit {
 			vs.context.Log.Warnf("skipping property %s, explicit overrides are not allowed by property rules", prop.Name)
@@ -151,7 +164,7 @@ func (vs *Visitor) loadProperties(layer *api.Layer, implicit, explicit config.Pr
 		}
 
 		if p.Source != nil {
-			val := vs.store.Read(*layer, prop.Name, prop.Sensitive(), p.Source, layer.Config)
+			val := vs.read(readKey{layer: layer.Name, key: prop.Name, source: p.Source}, *layer, prop.Sensitive(), p.Source)
 			if val != nil {
 				prop.SetValue(val)
 			}
@@ -170,7 +183,7 @@ func (vs *Visitor) loadProperties(layer *api.Layer, implicit, explicit config.Pr
 				f := api.NewFormatter(fc, vs.context.Log)
 				k := f.FormattingKey()
 
-				fval := vs.store.Read(*layer, k, prop.Sensitive(), fc.Source, layer.Config)
+				fval := vs.read(readKey{layer: layer.Name, key: k, source: fc.Source}, *layer, prop.Sensitive(), fc.Source)
 				if fval != nil {
 					optional := fc.Optional != nil && *fc.Optional
 
@@ -221,8 +234,161 @@ func (vs *Visitor) loadProperties(layer *api.Layer, implicit, explicit config.Pr
 	}
 }
 
-func (vs *Visitor) newProperty(name, description string, source string, sensitive bool, rules config.RuleConfig, formatting []config.FormattingConfig) (property api.Property, isNew bool) {
-	property, isNew = api.NewProperty(vs.properties, name, description, source, sensitive, rules, formatting)
+// resolve reads the values of remote sources for all layers ahead of time using a bounded number of
+// concurrent reads, values are applied in layer order when properties are loaded
+func (vs *Visitor) resolve(layers []api.Layer, layerProperties []config.PropertyList) {
+	requests := make([]readRequest, 0)
+	implicit := config.PropertyList{}
+
+	add := func(rk readKey, layer api.Layer, source *config.ValueSourceConfig) {
+		if source == nil || !remoteSource(source.SourceType()) {
+			return
+		}
+		if _, ok := vs.resolved[rk]; ok {
+			return
+		}
+		vs.resolved[rk] = nil
+		requests = append(requests, readRequest{
+			readKey: rk,
+			layer:   layer,
+			request: store.ReadRequest{Key: rk.key, Source: source, SourceConfig: layer.Config},
+		})
+	}
+
+	// rules are defined where a property is first defined, overrides not allowed by rules are never read
+	rules := make(map[string]config.RuleConfig)
+	rulesFor := func(p config.PropertyConfig) config.RuleConfig {
+		if r, ok := rules[p.Name]; ok {
+			return r
+		}
+		rules[p.Name] = p.Rules
+		return p.Rules
+	}
+
+	for i, layer := range layers {
+		explicit := layerProperties[i]
+
+		for _, p := range implicit.Remove(explicit) {
+			if !rulesFor(p).Override.AllowImplicit {
+				continue
+			}
+			for _, s := range layer.ImplicitSources {
+				add(readKey{layer: layer.Name, key: p.Name, implicit: s}, layer, vs.implicitValueSource(s, layer))
+			}
+		}
+
+		for _, p := range explicit {
+			if !rulesFor(p).Override.AllowExplicit && !layer.IsBaseLayer() {
+				continue
+			}
+			add(readKey{layer: layer.Name, key: p.Name, source: p.Source}, layer, p.Source)
+			for _, fc := range p.Format {
+				if f := api.NewFormatter(fc, vs.context.Log); f != nil {
+					add(readKey{layer: layer.Name, key: f.FormattingKey(), source: fc.Source}, layer, fc.Source)
+				}
+			}
+		}
+
+		implicit = explicit.Merge(implicit)
+	}
+
+	if len(requests) == 0 {
+		return
+	}
+
+	storeRequests := make([]store.ReadRequest, len(requests))
+	for i, r := range requests {
+		storeRequests[i] = r.request
+	}
+	vs.store.Prefetch(storeRequests)
+
+	workers := vs.context.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	vs.context.Log.Debugf("resolving %d values from remote sources (concurrency=%d)", len(requests), workers)
+
+	// values are read as non sensitive, sensitivity is applied when properties are loaded
+	values := make([]api.Value, len(requests))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := requests[i]
+				values[i] = vs.store.Read(r.layer, r.key, false, r.request.Source, r.request.SourceConfig)
+			}
+		}()
+	}
+	for i := range requests {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, r := range requests {
+		vs.resolved[r.readKey] = values[i]
+	}
+}
+
+// read returns the resolved value for a read, falling back to reading from the store
+func (vs *Visitor) read(rk readKey, layer api.Layer, sensitive bool, source *config.ValueSourceConfig) api.Value {
+	if v, ok := vs.resolved[rk]; ok && v != nil {
+		return api.NewValue(v.Source(), v.Key(), v.Raw(), v.Error(), sensitive || v.Sensitive())
+	}
+	return vs.store.Read(layer, rk.key, sensitive, source, layer.Config)
+}
+
+func remoteSource(t config.SourceType) bool {
+	switch t {
+	case config.SourceTypeAwsParameterStore, config.SourceTypeAwsSecretsManager, config.SourceTypeVault, config.SourceTypePlugin:
+		return true
+	default:
+		return false
+	}
+}
+
+func (vs *Visitor) implicitValueSource(s config.SourceType, layer api.Layer) *config.ValueSourceConfig {
+	switch s {
+	case config.SourceTypeAwsParameterStore:
+		return &config.ValueSourceConfig{
+			AwsParameterStore: &config.ValueFromAwsParameterStore{},
+		}
+	case config.SourceTypeAwsSecretsManager:
+		return &config.ValueSourceConfig{
+			AwsSecretsManager: &config.ValueFromAwsSecretsManager{},
+		}
+	case config.SourceTypeEnvironment:
+		return &config.ValueSourceConfig{
+			Environment: &config.ValueFromEnvironment{},
+		}
+	case config.SourceTypeVault:
+		return &config.ValueSourceConfig{
+			Vault: &config.ValueFromVault{},
+		}
+	case config.SourceTypeEncryptedFile:
+		return &config.ValueSourceConfig{
+			EncryptedFile: &config.ValueFromEncryptedFile{},
+		}
+	case config.SourceTypeSops:
+		return &config.ValueSourceConfig{
+			Sops: &config.ValueFromSops{},
+		}
+	default:
+		// plugins are referenced by name
+		if _, ok := vs.context.Manifest.Config.Sources.Plugin(string(s), layer.Config); ok {
+			return &config.ValueSourceConfig{
+				Plugin: &config.ValueFromPlugin{Name: string(s)},
+			}
+		}
+		return nil
+	}
+}
+
+func (vs *Visitor) newProperty(name, description string, source string, sensitive bool, propertyType config.PropertyType, rules config.RuleConfig, formatting []config.FormattingConfig) (property api.Property, isNew bool) {
+	property, isNew = api.NewProperty(vs.properties, name, description, source, sensitive, propertyType, rules, formatting)
 	if isNew {
 		vs.properties = append(vs.properties, property)
 	}
@@ -0,0 +1,140 @@
+package visitor_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotnetmentor/racoon/internal/api"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/visitor"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestVisitor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Visitor Suite")
+}
+
+// slowVault serves every KV v2 path with its own path as the value, after a random delay. Requested paths are recorded.
+func slowVault(requested *sync.Map) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(rand.Intn(20)) * time.Millisecond)
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		requested.Store(path, true)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{"value": path},
+			},
+		})
+	}))
+}
+
+var _ = Describe("Visitor", func() {
+	var server *httptest.Server
+	var requested *sync.Map
+	var ctx config.AppContext
+
+	vaultSource := func(key string) *config.ValueSourceConfig {
+		return &config.ValueSourceConfig{Vault: &config.ValueFromVault{Key: key}}
+	}
+
+	BeforeEach(func() {
+		requested = &sync.Map{}
+		server = slowVault(requested)
+		os.Setenv("VAULT_TOKEN", "test-token")
+
+		properties := config.PropertyList{}
+		layer1 := config.PropertyList{}
+		for _, name := range []string{"One", "Two", "Three", "Four", "Five", "Six"} {
+			properties = append(properties, config.PropertyConfig{Name: name, Rules: config.DefaultPropertyRules, Source: vaultSource("base/{key}")})
+			layer1 = append(layer1, config.PropertyConfig{Name: name, Rules: config.DefaultPropertyRules, Source: vaultSource("layer1/{key}")})
+		}
+
+		ctx = config.AppContext{
+			Context: context.Background(),
+			Log:     logrus.New(),
+			Manifest: config.Manifest{
+				MetadataConfig: config.MetadataConfig{Name: "test"},
+				Config: config.Config{
+					Sources: config.SourceConfig{
+						Vault: config.VaultConfig{Address: server.URL, DefaultKey: "implicit/{key}"},
+					},
+				},
+				Properties: properties,
+				Layers: config.LayerList{
+					{Name: "layer1", Properties: layer1},
+					{Name: "layer2", ImplicitSources: []config.SourceType{config.SourceTypeVault}},
+				},
+			},
+			Concurrency: 8,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Unsetenv("VAULT_TOKEN")
+	})
+
+	It("keeps layer precedence when resolving values concurrently", func() {
+		visit := visitor.New(ctx)
+		Expect(visit.Init([]string{}, []string{})).To(Succeed())
+
+		n := 0
+		err := visit.Property(func(p api.Property, err error) (bool, error) {
+			Expect(err).To(Not(HaveOccurred()))
+			key := strings.ToLower(p.Name)
+
+			sources := make([]string, 0)
+			raw := make([]string, 0)
+			for _, v := range p.Values() {
+				sources = append(sources, v.Source().Layer().Name)
+				raw = append(raw, v.Raw())
+			}
+
+			Expect(sources).To(Equal([]string{"base", "layer1", "layer2"}))
+			Expect(raw).To(Equal([]string{"base/" + key, "layer1/" + key, "implicit/" + key}))
+			Expect(p.Value().Raw()).To(Equal("implicit/" + key))
+			n++
+			return true, nil
+		})
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(n).To(Equal(6))
+	})
+
+	It("never reads sources of overrides not allowed by property rules", func() {
+		locked := config.DefaultPropertyRules
+		locked.Override = config.OverrideRuleConfig{AllowImplicit: false, AllowExplicit: false}
+		replace := "{x}"
+
+		ctx.Manifest.Properties = append(ctx.Manifest.Properties, config.PropertyConfig{Name: "Locked", Rules: locked, Source: vaultSource("base/{key}")})
+		ctx.Manifest.Layers[0].Properties = append(ctx.Manifest.Layers[0].Properties, config.PropertyConfig{
+			Name:   "Locked",
+			Rules:  config.DefaultPropertyRules,
+			Source: vaultSource("layer1/{key}"),
+			Format: []config.FormattingConfig{{Replace: &replace, Source: vaultSource("layer1/formatter")}},
+		})
+
+		visit := visitor.New(ctx)
+		Expect(visit.Init([]string{}, []string{})).To(Succeed())
+
+		_, ok := requested.Load("base/locked")
+		Expect(ok).To(BeTrue())
+		for _, path := range []string{"layer1/locked", "layer1/formatter", "implicit/locked"} {
+			_, ok := requested.Load(path)
+			Expect(ok).To(BeFalse(), "expected %s not to be read", path)
+		}
+		_, ok = requested.Load("implicit/one")
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -14,7 +14,7 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-const metadataExitCode string = "exitcode"
+const metadataExitCode string = command.MetadataExitCode
 
 //go:embed ui/dist
 var staticFiles embed.FS
@@ -86,12 +86,24 @@ func createApp() (*cli.App, config.AppContext) {
 				Usage:   "sets the log level",
 				Value:   "info",
 			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "sets the maximum number of concurrent reads from remote sources",
+				Value: 4,
+			},
 		},
 		Commands: []*cli.Command{
 			command.Export(metadata),
 			command.Read(metadata),
 			command.Write(metadata),
+			command.Run(metadata),
+			command.Explain(metadata),
+			command.Validate(metadata),
+			command.Import(metadata),
+			command.Value(metadata),
 			command.Config(metadata),
+			command.Backend(metadata),
+			command.Drift(metadata),
 			command.UI(metadata, staticFiles),
 		},
 		Before: func(c *cli.Context) error {
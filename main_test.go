@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/andreyvit/diff"
+	"github.com/dotnetmentor/racoon/internal/config"
+	"github.com/dotnetmentor/racoon/internal/store"
 	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
 )
 
 var cases = []struct {
@@ -79,3 +84,235 @@ func cleanupTestOutput() {
 		}
 	}
 }
+
+func TestRunCommand(t *testing.T) {
+	runCases := []struct {
+		name             string
+		script           string
+		expectedExitCode interface{}
+	}{
+		{"success", `echo "$API_KEY $PORT" > "$RACOON_TEST_OUTPUT"`, nil},
+		{"exit_code", `echo "$API_KEY $PORT" > "$RACOON_TEST_OUTPUT"; exit 3`, 3},
+		{"signaled", `echo "$API_KEY $PORT" > "$RACOON_TEST_OUTPUT"; kill -TERM $$`, 128 + 15},
+	}
+
+	for _, tcase := range runCases {
+		tt := tcase
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "env")
+			t.Setenv("RACOON_TEST_OUTPUT", output)
+
+			app, _ := createApp()
+			args := []string{os.Args[0], "-manifest=./testdata/racoon.run.yaml", "-loglevel=error", "run", "--", "sh", "-c", tt.script}
+			if err := app.Run(args); err != nil {
+				t.Fatal(err)
+			}
+
+			if exitCode := app.Metadata[metadataExitCode]; exitCode != tt.expectedExitCode {
+				t.Errorf("expected exit code %v, got %v", tt.expectedExitCode, exitCode)
+			}
+
+			env, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(env) != "s3cr3t 8080\n" {
+				t.Errorf("expected values to be set as environment variables, got %q", env)
+			}
+		})
+	}
+}
+
+func TestDriftCommand(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "racoon.yaml")
+	err := os.WriteFile(manifest, []byte(fmt.Sprintf(`name: racoon-drift-tests
+
+backend:
+  enabled: true
+  store:
+    local:
+      path: %s
+  encryption:
+    passphrase: {}
+
+properties:
+  - name: ApiKey
+    sensitive: true
+    source:
+      env:
+        key: DRIFT_API_KEY
+
+  - name: Port
+    source:
+      env:
+        key: DRIFT_PORT
+
+  - name: Token
+    source:
+      exec:
+        command: sh
+        args: ["-c", "test -z \"$DRIFT_FAIL\" && echo token || exit 1"]
+
+  - name: Excluded
+    default: excluded
+
+outputs:
+  - type: dotenv
+`, filepath.Join(dir, "backend"))), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RACOON_BACKEND_PASSPHRASE", "passphrase")
+	t.Setenv("DRIFT_API_KEY", "s3cr3t")
+	t.Setenv("DRIFT_PORT", "8080")
+
+	run := func(args ...string) (interface{}, error) {
+		app, _ := createApp()
+		app.Writer = &strings.Builder{}
+		err := app.Run(append([]string{os.Args[0], "-manifest=" + manifest, "-loglevel=error"}, args...))
+		return app.Metadata[metadataExitCode], err
+	}
+
+	if _, err := run("export", "-output=dotenv", "-path="+filepath.Join(dir, ".env"), "-exclude=Excluded"); err != nil {
+		t.Fatal(err)
+	}
+
+	driftCases := []struct {
+		name             string
+		port             string
+		fail             string
+		args             []string
+		expectedExitCode interface{}
+		expectedError    bool
+	}{
+		{"no_drift_using_export_filters", "8080", "", []string{}, nil, false},
+		{"drift", "8081", "", []string{}, 2, false},
+		{"drift_excluded", "8081", "", []string{"-exclude=Port"}, nil, false},
+		{"error", "8080", "", []string{"-version=20200101T000000Z-000000000000"}, nil, true},
+		{"source_error", "8081", "true", []string{}, nil, true},
+	}
+
+	for _, tcase := range driftCases {
+		tt := tcase
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DRIFT_PORT", tt.port)
+			t.Setenv("DRIFT_FAIL", tt.fail)
+
+			exitCode, err := run(append([]string{"drift"}, tt.args...)...)
+			if tt.expectedError != (err != nil) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if exitCode != tt.expectedExitCode {
+				t.Errorf("expected exit code %v, got %v", tt.expectedExitCode, exitCode)
+			}
+		})
+	}
+}
+
+func TestValueMoveCommand(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "racoon.yaml")
+	err := os.WriteFile(manifest, []byte(fmt.Sprintf(`name: racoon-value-tests
+
+config:
+  parameters:
+    - key: context
+      required: true
+
+properties:
+  - name: Token
+    sensitive: true
+    source:
+      encryptedFile:
+        path: %s
+
+layers:
+  - name: dev
+    match:
+      - context = dev
+    properties:
+      - name: Token
+        source:
+          encryptedFile:
+            path: %s
+`, filepath.Join(dir, "base.yaml"), filepath.Join(dir, "dev.yaml"))), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RACOON_ENCRYPTED_FILE_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	ctx := config.AppContext{Context: context.Background(), Log: log}
+	ef := &store.EncryptedFile{}
+	base := filepath.Join(dir, "base.yaml") + "#token"
+	dev := filepath.Join(dir, "dev.yaml") + "#token"
+
+	moveCases := []struct {
+		name   string
+		target string
+	}{
+		{"empty_target", ""},
+		{"target_with_value", "s3cr3t"},
+	}
+
+	for _, tcase := range moveCases {
+		tt := tcase
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ef.Write(ctx, base, "s3cr3t", "", config.EncryptedFileConfig{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := ef.Delete(ctx, dev); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.target) > 0 {
+				if err := ef.Write(ctx, dev, tt.target, "", config.EncryptedFileConfig{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			app, _ := createApp()
+			args := []string{os.Args[0], "-manifest=" + manifest, "-loglevel=error", "value", "move", "-p=context=dev", "--yes", "--from-layer=base", "--to-layer=dev", "Token"}
+			if err := app.Run(args); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, found, err := ef.ReadKey(ctx, base, config.EncryptedFileConfig{}); err != nil || found {
+				t.Errorf("expected value to be deleted from the source, found=%v err=%v", found, err)
+			}
+			if v, found, err := ef.ReadKey(ctx, dev, config.EncryptedFileConfig{}); err != nil || !found || v != "s3cr3t" {
+				t.Errorf("expected value to be moved to the target, got %q found=%v err=%v", v, found, err)
+			}
+		})
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	validateCases := []struct {
+		name          string
+		args          []string
+		expectedError string
+	}{
+		{"defined_parameters", []string{}, ""},
+		{"undefined_parameter", []string{"-p=context=dev"}, "parameter context, provided but not defined"},
+		{"undefined_matrix_parameter", []string{"--matrix=context=dev,prod"}, "parameter context, provided but not defined"},
+	}
+
+	for _, tcase := range validateCases {
+		tt := tcase
+		t.Run(tt.name, func(t *testing.T) {
+			app, _ := createApp()
+			app.Writer = &strings.Builder{}
+			args := append([]string{os.Args[0], "-manifest=./testdata/racoon.run.yaml", "-loglevel=error", "validate"}, tt.args...)
+
+			err := app.Run(args)
+			if len(tt.expectedError) == 0 && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if len(tt.expectedError) > 0 && (err == nil || err.Error() != tt.expectedError) {
+				t.Errorf("expected error %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}